@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// checkpoints tracks the progress of the inputs, or is nil if -checkpoint was
+// not given.
+var checkpoints *checkpointer
+
+// checkpoint is the position in an input up to which every line has been
+// delivered, dead-lettered or discarded as unrecognized. Done is set once the
+// whole input has been.
+type checkpoint struct {
+	Offset int64 `json:"offset"`
+	Line   int64 `json:"line"`
+	Done   bool  `json:"done,omitempty"`
+}
+
+// checkpointer persists the progress of the inputs in a JSON file, keyed by
+// their path.
+type checkpointer struct {
+	mu     sync.Mutex
+	path   string
+	all    map[string]checkpoint
+	inputs map[string]*progress
+}
+
+// openCheckpointer loads the checkpoint file found at path, if any.
+func openCheckpointer(path string) (*checkpointer, error) {
+	c := &checkpointer{
+		path:   path,
+		all:    make(map[string]checkpoint),
+		inputs: make(map[string]*progress),
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(blob, &c.all); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	return c, nil
+}
+
+// track starts tracking the progress of the input known as key, from its
+// beginning or, when resume is true, from its last saved checkpoint. The input
+// is not done again until it is read to the end, since a followed file may
+// have grown. It returns nil when c is nil.
+func (c *checkpointer) track(key string, resume bool) *progress {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := &progress{pending: make(map[int64]int64), last: -1}
+	if resume {
+		p.pos = c.all[key]
+		p.pos.Done = false
+	}
+	c.inputs[key] = p
+	return p
+}
+
+// done reports whether the input known as key was completely processed by a
+// previous run, and it is being resumed.
+func (c *checkpointer) done(key string) bool {
+	if c == nil || !*resume {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.all[key].Done
+}
+
+// save writes the checkpoint file, replacing it atomically.
+func (c *checkpointer) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	for key, p := range c.inputs {
+		c.all[key] = p.position()
+	}
+	blob, err := json.MarshalIndent(c.all, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	blob = append(blob, '\n')
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// progress collects the acknowledgements of the lines of an input, which the
+// digesters send out of order, to find the checkpoint of the input. Its
+// methods do nothing when it is nil.
+type progress struct {
+	mu  sync.Mutex
+	pos checkpoint
+
+	// pending holds the offsets of the lines acknowledged ahead of pos, by
+	// line number.
+	pending map[int64]int64
+
+	// stop is the first line that could not be delivered, if any. The
+	// checkpoint can not move past it.
+	stop int64
+
+	// last is the number of the last line once the input has been read to
+	// the end, or -1.
+	last int64
+}
+
+func (p *progress) position() checkpoint {
+	if p == nil {
+		return checkpoint{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pos
+}
+
+// ack acknowledges the line that ends at offset. ok is false when the line
+// could not be delivered.
+func (p *progress) ack(line, offset int64, ok bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop > 0 && line >= p.stop {
+		return
+	}
+	if !ok {
+		p.stop = line
+		return
+	}
+	p.pending[line] = offset
+	for {
+		next := p.pos.Line + 1
+		offset, found := p.pending[next]
+		if !found {
+			break
+		}
+		delete(p.pending, next)
+		p.pos = checkpoint{Offset: offset, Line: next}
+	}
+	p.pos.Done = p.pos.Line == p.last
+}
+
+// finish records that the input ends after line.
+func (p *progress) finish(line int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = line
+	p.pos.Done = p.pos.Line == p.last
+}
+
+// checkpointKey returns the key of an input in the checkpoint file.
+func checkpointKey(input string) string {
+	if input == "-" {
+		return input
+	}
+	if abs, err := filepath.Abs(input); err == nil {
+		return abs
+	}
+	return input
+}
+
+// seek moves to offset in file, which must not be shorter.
+func seek(file *os.File, offset int64) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < offset {
+		return fmt.Errorf("input is shorter than the checkpoint (%d < %d bytes)", info.Size(), offset)
+	}
+	_, err = file.Seek(offset, os.SEEK_SET)
+	return err
+}
+
+// skip reads past the first offset bytes of r.
+func skip(r io.Reader, offset int64) error {
+	n, err := io.CopyN(ioutil.Discard, r, offset)
+	if err == io.EOF {
+		return fmt.Errorf("input is shorter than the checkpoint (%d < %d bytes)", n, offset)
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ack is an acknowledgement of a line, ending at offset.
+type ack struct {
+	line, offset int64
+	ok           bool
+}
+
+func TestProgress(t *testing.T) {
+	var tests = []struct {
+		name   string
+		acks   []ack
+		finish int64 // 0 unless the input was read to the end
+		expec  checkpoint
+	}{
+		{
+			"in order",
+			[]ack{{1, 10, true}, {2, 20, true}, {3, 30, true}},
+			0,
+			checkpoint{Offset: 30, Line: 3},
+		},
+		{
+			"out of order",
+			[]ack{{3, 30, true}, {1, 10, true}, {4, 40, true}, {2, 20, true}},
+			0,
+			checkpoint{Offset: 40, Line: 4},
+		},
+		{
+			"gap",
+			[]ack{{1, 10, true}, {3, 30, true}, {4, 40, true}},
+			0,
+			checkpoint{Offset: 10, Line: 1},
+		},
+		{
+			"lost line",
+			[]ack{{1, 10, true}, {3, 30, true}, {2, 20, false}, {4, 40, true}},
+			0,
+			checkpoint{Offset: 10, Line: 1},
+		},
+		{
+			"lost line acknowledged late",
+			[]ack{{3, 30, true}, {2, 20, false}, {1, 10, true}},
+			0,
+			checkpoint{Offset: 10, Line: 1},
+		},
+		{
+			"finished",
+			[]ack{{2, 20, true}, {1, 10, true}},
+			2,
+			checkpoint{Offset: 20, Line: 2, Done: true},
+		},
+		{
+			"finished with a lost line",
+			[]ack{{1, 10, true}, {2, 20, false}},
+			2,
+			checkpoint{Offset: 10, Line: 1},
+		},
+	}
+	for _, tt := range tests {
+		c := &checkpointer{all: make(map[string]checkpoint), inputs: make(map[string]*progress)}
+		p := c.track("input", false)
+		if tt.finish > 0 {
+			p.finish(tt.finish)
+		}
+		for _, a := range tt.acks {
+			p.ack(a.line, a.offset, a.ok)
+		}
+		if actual := p.position(); actual != tt.expec {
+			t.Errorf("%s: expected %+v, actual %+v", tt.name, tt.expec, actual)
+		}
+	}
+}
+
+func TestCheckpointer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+	defer func(r bool) { *resume = r }(*resume)
+
+	c, err := openCheckpointer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial := c.track("partial", false)
+	partial.ack(2, 20, true)
+	partial.ack(1, 10, true)
+	finished := c.track("finished", false)
+	finished.ack(1, 10, true)
+	finished.finish(1)
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = openCheckpointer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		key    string
+		resume bool
+		done   bool
+		expec  checkpoint
+	}{
+		{"partial", true, false, checkpoint{Offset: 20, Line: 2}},
+		{"finished", true, true, checkpoint{Offset: 10, Line: 1}},
+		{"finished", false, false, checkpoint{}},
+		{"unknown", true, false, checkpoint{}},
+	}
+	for _, tt := range tests {
+		*resume = tt.resume
+		if done := c.done(tt.key); done != tt.done {
+			t.Errorf("done(%s, resume=%t): expected %t, actual %t", tt.key, tt.resume, tt.done, done)
+		}
+		if actual := c.track(tt.key, tt.resume).position(); actual != tt.expec {
+			t.Errorf("track(%s, resume=%t): expected %+v, actual %+v", tt.key, tt.resume, tt.expec, actual)
+		}
+	}
+}
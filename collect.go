@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+	"github.com/sevein/nfdmp2rds/netflow"
+	"github.com/sevein/nfdmp2rds/sflow"
+)
+
+var (
+	netflowAddr     = flag.String("netflow", "", "Receive NetFlow v5, v9 and IPFIX packets on this UDP address, e.g. :2055, instead of reading a file")
+	ipfixAddr       = flag.String("ipfix", "", "Accept IPFIX connections on this TCP address, e.g. :4739, instead of reading a file")
+	sflowAddr       = flag.String("sflow", "", "Receive sFlow v5 datagrams on this UDP address, e.g. :6343, instead of reading a file")
+	templateTimeout = flag.Duration("templateTimeout", netflow.DefaultTemplateTimeout, "Forget the NetFlow v9 and IPFIX templates not refreshed for this long, 0 to keep them")
+)
+
+// listen starts a collector on the addresses given with -netflow, -ipfix and
+// -sflow.
+func listen() (*netflow.Collector, error) {
+	c := netflow.NewCollector()
+	c.Decoder.TemplateTimeout = *templateTimeout
+	if *netflowAddr != "" {
+		addr, err := c.ListenUDP(*netflowAddr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		logger.Printf("Listening for NetFlow and IPFIX packets on udp %s.", addr)
+	}
+	if *ipfixAddr != "" {
+		addr, err := c.ListenTCP(*ipfixAddr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		logger.Printf("Listening for IPFIX connections on tcp %s.", addr)
+	}
+	if *sflowAddr != "" {
+		addr, err := c.ListenPacket(*sflowAddr, decodeSFlow)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		logger.Printf("Listening for sFlow datagrams on udp %s.", addr)
+	}
+	return c, nil
+}
+
+// collector starts a goroutine that sends the flows received by c on the
+// record channel until stop is closed. Packets that can not be decoded are
+// logged, and dropped unless some flows could be decoded. If done is closed,
+// collector abandons its work.
+func collector(done, stop <-chan struct{}, c *netflow.Collector) (<-chan record, <-chan error) {
+	lines := make(chan record)
+	errc := make(chan error, 1)
+	go func() {
+		<-stop
+		c.Close()
+	}()
+	go func() {
+		defer close(lines)
+		for {
+			flows, err := c.Read()
+			if _, ok := err.(*netflow.PacketError); ok {
+				logger.Printf("Error decoding packet: %s", err)
+			} else if err == netflow.ErrClosed {
+				errc <- nil
+				return
+			} else if err != nil {
+				errc <- err
+				return
+			}
+			for _, f := range flows {
+				select {
+				case <-done:
+					errc <- nil
+					return
+				case lines <- record{flow: f}:
+				}
+			}
+		}
+	}()
+	return lines, errc
+}
+
+// decodeSFlow decodes an sFlow datagram as it is received.
+func decodeSFlow(b []byte, agent string) ([]*entry.Flow, error) {
+	return sflow.Decode(b, time.Now())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Magic bytes of the compressed formats read transparently.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// decompressor returns a reader of the data decompressed from br when it
+// starts with the magic bytes of gzip, bzip2, zstd or xz, along with the name
+// of the format. It returns nil when br is not compressed.
+func decompressor(br *bufio.Reader) (io.ReadCloser, string, error) {
+	head, _ := br.Peek(len(xzMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		r, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return r, "gzip", nil
+	case bytes.HasPrefix(head, bzip2Magic) && len(head) > 3 && head[3] >= '1' && head[3] <= '9':
+		return ioutil.NopCloser(bzip2.NewReader(br)), "bzip2", nil
+	case bytes.HasPrefix(head, zstdMagic):
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return d.IOReadCloser(), "zstd", nil
+	case bytes.HasPrefix(head, xzMagic):
+		r, err := xz.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return ioutil.NopCloser(r), "xz", nil
+	}
+	return nil, "", nil
+}
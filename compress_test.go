@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const compressedLine = "2|1463425844|692\n"
+
+// bzip2Line is compressedLine compressed with bzip2, which the standard
+// library can not write.
+var bzip2Line = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x3f, 0x76,
+	0xe9, 0x10, 0x00, 0x00, 0x02, 0x48, 0x80, 0x00, 0x10, 0x3f, 0x60, 0x00,
+	0x04, 0x20, 0x00, 0x22, 0x03, 0x46, 0x21, 0x00, 0x30, 0xb6, 0xf8, 0x22,
+	0xd6, 0xaa, 0x4c, 0x81, 0xe2, 0xee, 0x48, 0xa7, 0x0a, 0x12, 0x07, 0xee,
+	0xdd, 0x22, 0x00,
+}
+
+// compress returns compressedLine written with the writer returned by fn.
+func compress(t *testing.T, fn func(io.Writer) (io.WriteCloser, error)) []byte {
+	var buf bytes.Buffer
+	w, err := fn(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, compressedLine); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressor(t *testing.T) {
+	var tests = []struct {
+		name   string
+		input  []byte
+		format string
+	}{
+		{"gzip", compress(t, func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }), "gzip"},
+		{"bzip2", bzip2Line, "bzip2"},
+		{"zstd", compress(t, func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }), "zstd"},
+		{"xz", compress(t, func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }), "xz"},
+		{"plain", []byte(compressedLine), ""},
+		{"BZ text", []byte("BZh, not bzip2\n"), ""},
+		{"short", []byte{0x1f}, ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		br := bufio.NewReader(bytes.NewReader(tt.input))
+		r, format, err := decompressor(br)
+		if err != nil {
+			t.Errorf("decompressor(%s): %s", tt.name, err)
+			continue
+		}
+		if format != tt.format {
+			t.Errorf("decompressor(%s): expected format %q, actual %q", tt.name, tt.format, format)
+			continue
+		}
+		if r == nil {
+			// The input is read as it is, and must not have been consumed.
+			b, _ := ioutil.ReadAll(br)
+			if !bytes.Equal(b, tt.input) {
+				t.Errorf("decompressor(%s): expected the input to be left unread", tt.name)
+			}
+			continue
+		}
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Errorf("decompressor(%s): %s", tt.name, err)
+			continue
+		}
+		if string(b) != compressedLine {
+			t.Errorf("decompressor(%s): expected %q, actual %q", tt.name, compressedLine, b)
+		}
+	}
+}
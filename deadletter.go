@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+// deadLetters is where the lines that could not be processed are kept, or nil
+// if -deadLetter was not given.
+var deadLetters *deadLetter
+
+// deadLetterRecord is the JSON document written for each line. Header is the
+// csv header that the line followed, so its columns are known when it is
+// replayed.
+type deadLetterRecord struct {
+	Time   string `json:"time"`
+	Error  string `json:"error"`
+	Line   string `json:"line"`
+	Header string `json:"header,omitempty"`
+}
+
+// deadLetter appends the lines that could not be parsed or written to a file,
+// one JSON document per line, so they can be inspected and replayed later.
+type deadLetter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func openDeadLetter(path string) (*deadLetter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// add records the original line of rec and the reason why it failed, and
+// reports whether it was kept. It does nothing when d is nil.
+func (d *deadLetter) add(rec record, reason error) bool {
+	if d == nil {
+		return false
+	}
+	dl := deadLetterRecord{
+		Time:  time.Now().UTC().Format(time.RFC3339),
+		Error: reason.Error(),
+		Line:  rec.String(),
+	}
+	if rec.layout != nil {
+		dl.Header = rec.layout.Header()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err := d.enc.Encode(dl)
+	if err != nil {
+		logger.Printf("Error writing dead letter: %s", err)
+		return false
+	}
+	return true
+}
+
+func (d *deadLetter) Close() error {
+	return d.file.Close()
+}
+
+// replayer starts a goroutine that decodes the dead letter records received
+// and sends their original lines, so they can be processed again. Lines that
+// followed a csv header are read in its layout. Records that can not be
+// decoded are logged and skipped.
+func replayer(done <-chan struct{}, records <-chan record) <-chan record {
+	lines := make(chan record)
+	go func() {
+		defer close(lines)
+		layouts := make(map[string]*entry.Layout)
+		for r := range records {
+			var dl deadLetterRecord
+			if err := json.Unmarshal([]byte(r.line), &dl); err != nil {
+				logger.Printf("Error decoding dead letter: %s", err)
+				r.ack(true)
+				continue
+			}
+			r.line = dl.Line
+			if dl.Header != "" {
+				layout, ok := layouts[dl.Header]
+				if !ok {
+					layout, _ = entry.NewCSVLayout(dl.Header)
+					layouts[dl.Header] = layout
+				}
+				if layout != nil {
+					r.layout = layout
+				}
+			}
+			select {
+			case <-done:
+				return
+			case lines <- r:
+			}
+		}
+	}()
+	return lines
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+func TestDeadLetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deadletter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dead.jsonl")
+	d, err := openDeadLetter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := entry.NewCSVLayout("ts,sa,da,ibyt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		rec    record
+		header string
+	}{
+		{record{line: "2|1463425844|692"}, ""},
+		{record{line: "2016-05-16 19:10:44,142.58.103.21,217.12.24.33,x", layout: layout}, "ts,sa,da,ibyt"},
+	}
+	for _, tt := range tests {
+		if !d.add(tt.rec, errors.New("failed")) {
+			t.Errorf("add(%s): expected record to be kept", tt.rec.line)
+		}
+	}
+	d.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records := make(chan record, len(tests))
+	for s := bufio.NewScanner(file); s.Scan(); {
+		records <- record{line: s.Text()}
+	}
+	close(records)
+	done := make(chan struct{})
+	defer close(done)
+	lines := replayer(done, records)
+	for _, tt := range tests {
+		r := <-lines
+		var header string
+		if r.layout != nil {
+			header = r.layout.Header()
+		}
+		if r.line != tt.rec.line || header != tt.header {
+			t.Errorf("replay: expected %s (%q), actual %s (%q)", tt.rec.line, tt.header, r.line, header)
+		}
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	var tests = []struct {
+		input  string
+		line   string
+		header string
+	}{
+		{`{"time":"2016-05-16T19:10:44Z","error":"failed","line":"2|1463425844|692"}`, "2|1463425844|692", ""},
+		{`not a dead letter`, "", ""},
+		{`{"time":"2016-05-16T19:10:44Z","error":"failed","line":"a,b,c","header":"ts,sa,da"}`, "a,b,c", "ts,sa,da"},
+		{`{"time":"2016-05-16T19:10:44Z","error":"failed","line":"d,e,f","header":"ts,sa,da"}`, "d,e,f", "ts,sa,da"},
+		{`{"time":"2016-05-16T19:10:44Z","error":"failed","line":"g,h","header":"bytes,packets"}`, "g,h", ""},
+	}
+	records := make(chan record, len(tests))
+	for _, tt := range tests {
+		records <- record{line: tt.input}
+	}
+	close(records)
+	done := make(chan struct{})
+	defer close(done)
+	lines := replayer(done, records)
+	for _, tt := range tests {
+		if tt.line == "" {
+			continue
+		}
+		r, ok := <-lines
+		if !ok {
+			t.Fatalf("replay(%s): expected line", tt.input)
+		}
+		var header string
+		if r.layout != nil {
+			header = r.layout.Header()
+		}
+		if r.line != tt.line || header != tt.header {
+			t.Errorf("replay(%s): expected %s (%q), actual %s (%q)", tt.input, tt.line, tt.header, r.line, header)
+		}
+	}
+	if r, ok := <-lines; ok {
+		t.Errorf("replay: unexpected line %s", r.line)
+	}
+}
@@ -0,0 +1,75 @@
+package entry
+
+import (
+	"net"
+
+	"github.com/sevein/nfdmp2rds/geoip"
+)
+
+// geoLanguage is the language of the names of places.
+const geoLanguage = "en"
+
+// enricher completes the entry of a flow with data looked up elsewhere, once
+// the flow has been decoded. Enrichers are independent of each other, so any
+// of them can be disabled.
+type enricher struct {
+	enabled func() bool
+	enrich  func(e *NfdumpEntry, f *Flow)
+}
+
+// enrichers are applied in order by NewFlowEntry.
+var enrichers = []enricher{
+	{func() bool { return !*NoGeo }, enrichGeo},
+	{func() bool { return !*NoASN }, enrichASN},
+}
+
+// enrichGeo adds the geographic location of the endpoints.
+func enrichGeo(e *NfdumpEntry, f *Flow) {
+	e.GeoIPSrc = geoEntry(f.SrcAddr)
+	e.GeoIPDst = geoEntry(f.DstAddr)
+}
+
+// enrichASN adds the autonomous systems of the endpoints.
+func enrichASN(e *NfdumpEntry, f *Flow) {
+	e.SrcAs, e.SrcAsOrg = asEntry(f.SrcAddr, f.SrcAs)
+	e.DstAs, e.DstAsOrg = asEntry(f.DstAddr, f.DstAs)
+}
+
+// geoEntry looks up ip in the geographic databases. It returns nil when the
+// lookup fails.
+func geoEntry(ip net.IP) *GeoIPEntry {
+	geo, err := geoip.Geo(ip)
+	if err != nil {
+		return nil
+	}
+	e := &GeoIPEntry{
+		IsoCode:        geo.Country.IsoCode,
+		CityName:       geo.City.Names[geoLanguage],
+		PostalCode:     geo.Postal.Code,
+		AccuracyRadius: geo.Location.AccuracyRadius,
+		Latitude:       geo.Location.Latitude,
+		Longitude:      geo.Location.Longitude,
+	}
+	if len(geo.Subdivisions) > 0 {
+		e.SubdivisionIsoCode = geo.Subdivisions[0].IsoCode
+		e.SubdivisionName = geo.Subdivisions[0].Names[geoLanguage]
+	}
+	return e
+}
+
+// asEntry looks up the autonomous system of ip in the ASN database. The number
+// as given by the exporter is preferred when it is not zero, and the name of
+// the organization is only known when the database agrees with it.
+func asEntry(ip net.IP, as uint32) (uint32, string) {
+	a, err := geoip.ASN(ip)
+	if err != nil {
+		return as, ""
+	}
+	if as == 0 {
+		return a.Number, a.Organization
+	}
+	if as == a.Number {
+		return as, a.Organization
+	}
+	return as, ""
+}
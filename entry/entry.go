@@ -3,125 +3,206 @@
 package entry
 
 import (
-	"errors"
+	"encoding/binary"
 	"flag"
+	"fmt"
 	"net"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/sevein/nfdmp2rds/geoip"
+	"github.com/pquerna/ffjson/ffjson"
 )
 
 var (
 	// NoGeo lets the user decide if they want to use the geographic database.
 	NoGeo = flag.Bool("nogeo", false, "Do not use geographic database")
 
+	// NoASN lets the user decide if they want to use the ASN database.
+	NoASN = flag.Bool("noasn", false, "Do not use ASN database")
+
 	// Hostname is used in the JSON document.
 	Hostname = flag.String("hostname", "localhost", "Given hostname")
+
+	// Schema is the version of the JSON document generated.
+	Schema = flag.Int("schema", SchemaV1, "JSON schema version: 1 (string values) or 2 (typed values)")
+)
+
+// Versions of the JSON schema supported by Marshal.
+const (
+	SchemaV1 = 1
+	SchemaV2 = 2
 )
 
-// NfdumpEntry represents a nfdump entry
+// NfdumpEntry represents a nfdump entry. Its JSON encoding is the version 2
+// of the schema.
 type NfdumpEntry struct {
 	Host          string      `json:"host"`
-	InBytes       string      `json:"in_bytes"`
-	InPkts        string      `json:"in_pkts"`
-	Ipv4SrcAddr   string      `json:"ipv4_src_addr"`
-	Ipv4DstAddr   string      `json:"ipv4_dst_addr"`
-	Protocol      string      `json:"protocol"`
-	L4SrcPort     string      `json:"l4_src_port"`
-	L4DstPort     string      `json:"l4_dst_port"`
+	InBytes       uint64      `json:"in_bytes"`
+	InPkts        uint64      `json:"in_pkts"`
+	Ipv4SrcAddr   string      `json:"ipv4_src_addr,omitempty"`
+	Ipv4DstAddr   string      `json:"ipv4_dst_addr,omitempty"`
+	Ipv6SrcAddr   string      `json:"ipv6_src_addr,omitempty"`
+	Ipv6DstAddr   string      `json:"ipv6_dst_addr,omitempty"`
+	Protocol      uint8       `json:"protocol"`
+	L4SrcPort     uint16      `json:"l4_src_port"`
+	L4DstPort     uint16      `json:"l4_dst_port"`
+	SrcAs         uint32      `json:"src_as"`
+	DstAs         uint32      `json:"dst_as"`
+	SrcAsOrg      string      `json:"src_asn_org,omitempty"`
+	DstAsOrg      string      `json:"dst_asn_org,omitempty"`
+	InputSnmp     uint32      `json:"input_snmp"`
+	OutputSnmp    uint32      `json:"output_snmp"`
+	TcpFlags      uint8       `json:"tcp_flags"`
+	SrcTos        uint8       `json:"src_tos"`
 	FirstSwitched string      `json:"first_switched"`
 	LastSwitched  string      `json:"last_switched"`
+	SamplingRate  uint32      `json:"sampling_rate,omitempty"`
 	GeoIPSrc      *GeoIPEntry `json:"geoip_src,omitempty"`
 	GeoIPDst      *GeoIPEntry `json:"geoip_dst,omitempty"`
 }
 
-// GeoIPEntry identifiers geographic location
+// GeoIPEntry identifiers geographic location. Only the country is known
+// unless a city database is used.
 type GeoIPEntry struct {
-	IsoCode   string  `json:"iso_code,omitempty"`
-	Latitude  float64 `json:"latitude,omitempty"`
-	Longitude float64 `json:"longitude,omitempty"`
+	IsoCode            string  `json:"iso_code,omitempty"`
+	CityName           string  `json:"city_name,omitempty"`
+	SubdivisionIsoCode string  `json:"subdivision_iso_code,omitempty"`
+	SubdivisionName    string  `json:"subdivision_name,omitempty"`
+	PostalCode         string  `json:"postal_code,omitempty"`
+	AccuracyRadius     uint16  `json:"accuracy_radius,omitempty"`
+	Latitude           float64 `json:"latitude,omitempty"`
+	Longitude          float64 `json:"longitude,omitempty"`
 }
 
 const (
 	delim         = "|"
 	expectedParts = 24
+
+	// timeFormat is RFC 3339 with millisecond precision.
+	timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	// afInet is the address family used by nfdump for IPv4 flows. Anything
+	// else is considered to be IPv6.
+	afInet  = "2"
+	afInet6 = "10"
 )
 
-// NewNfdumpEntry creates a new NfdumpEntry
+// NewNfdumpEntry creates a new NfdumpEntry from a line of the nfdump pipe
+// format.
 func NewNfdumpEntry(s string) (*NfdumpEntry, error) {
-	parts := strings.Split(s, delim)
-	if len(parts) < expectedParts {
-		return nil, errors.New("Unrecognized nfdump entry")
+	f, err := ParseFlow(s)
+	if err != nil {
+		return nil, err
 	}
+	return NewFlowEntry(f), nil
+}
 
+// NewFlowEntry creates the NfdumpEntry of a flow, which is then completed by
+// the enrichers enabled.
+func NewFlowEntry(f *Flow) *NfdumpEntry {
 	e := NfdumpEntry{
 		Host:          *Hostname,
-		InBytes:       parts[23],
-		InPkts:        parts[22],
-		Protocol:      parts[5],
-		L4SrcPort:     parts[10],
-		L4DstPort:     parts[15],
-		FirstSwitched: ftime(parts[1]),
-		LastSwitched:  ftime(parts[3]),
+		InBytes:       f.Bytes,
+		InPkts:        f.Packets,
+		Protocol:      f.Protocol,
+		L4SrcPort:     f.SrcPort,
+		L4DstPort:     f.DstPort,
+		SrcAs:         f.SrcAs,
+		DstAs:         f.DstAs,
+		InputSnmp:     f.InputSnmp,
+		OutputSnmp:    f.OutputSnmp,
+		TcpFlags:      f.TcpFlags,
+		SrcTos:        f.SrcTos,
+		FirstSwitched: ftime(f.First),
+		LastSwitched:  ftime(f.Last),
+		SamplingRate:  f.SamplingRate,
 	}
 
-	if !*NoGeo {
-		ipv4Src, err := strlong2ip(parts[9])
-		if err != nil {
-			return nil, errors.New("Unrecognized IP address")
-		}
-		e.Ipv4SrcAddr = ipv4Src.String()
-
-		if geo, err := geoip.Geo(ipv4Src); err == nil {
-			e.GeoIPSrc = &GeoIPEntry{}
-			if geo.Country.IsoCode != "" {
-				e.GeoIPSrc.IsoCode = geo.Country.IsoCode
-			}
-			if geo.Location.Longitude != 0 {
-				e.GeoIPSrc.Longitude = geo.Location.Longitude
-			}
-			if geo.Location.Latitude != 0 {
-				e.GeoIPSrc.Latitude = geo.Location.Latitude
-			}
-		}
+	if len(f.SrcAddr) == net.IPv6len {
+		e.Ipv6SrcAddr = f.SrcAddr.String()
+	} else {
+		e.Ipv4SrcAddr = f.SrcAddr.String()
+	}
+	if len(f.DstAddr) == net.IPv6len {
+		e.Ipv6DstAddr = f.DstAddr.String()
+	} else {
+		e.Ipv4DstAddr = f.DstAddr.String()
+	}
 
-		ipv4Dst, err := strlong2ip(parts[14])
-		if err != nil {
-			return nil, errors.New("Unrecognized IP address")
-		}
-		e.Ipv4DstAddr = ipv4Dst.String()
-
-		if geo, err := geoip.Geo(ipv4Dst); err == nil {
-			e.GeoIPDst = &GeoIPEntry{}
-			if geo.Country.IsoCode != "" {
-				e.GeoIPDst.IsoCode = geo.Country.IsoCode
-			}
-			if geo.Location.Longitude != 0 {
-				e.GeoIPDst.Longitude = geo.Location.Longitude
-			}
-			if geo.Location.Latitude != 0 {
-				e.GeoIPDst.Latitude = geo.Location.Latitude
-			}
+	for _, en := range enrichers {
+		if en.enabled() {
+			en.enrich(&e, f)
 		}
 	}
 
-	return &e, nil
+	return &e
+}
+
+// Marshal returns the JSON encoding of e using the schema version selected
+// with the -schema flag.
+func (e *NfdumpEntry) Marshal() ([]byte, error) {
+	switch *Schema {
+	case SchemaV1:
+		return ffjson.Marshal(newNfdumpEntryV1(e))
+	case SchemaV2:
+		return ffjson.Marshal(e)
+	}
+	return nil, fmt.Errorf("Unknown schema version %d", *Schema)
+}
+
+// fieldParser parses the numeric fields of a pipe entry, remembering the
+// first error found so it can be checked once.
+// ffjson: skip
+type fieldParser struct {
+	parts []string
+	err   error
+}
+
+func (f *fieldParser) uint(i, bitSize int) uint64 {
+	n, err := strconv.ParseUint(f.parts[i], 10, bitSize)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n
+}
+
+// time parses the seconds and milliseconds found at i and j.
+func (f *fieldParser) time(i, j int) time.Time {
+	sec := f.uint(i, 64)
+	msec := f.uint(j, 16)
+	return time.Unix(int64(sec), int64(msec)*int64(time.Millisecond))
+}
+
+// straddr decodes an address spread across the four address fields of the
+// pipe format. nfdump prints each 32-bit word of the address in decimal, and
+// IPv4 addresses only use the last one.
+func straddr(words []string, ipv6 bool) (net.IP, error) {
+	if !ipv6 {
+		return strlong2ip(words[3])
+	}
+	return strlongs2ip(words)
 }
 
 func strlong2ip(s string) (net.IP, error) {
-	i, err := strconv.ParseInt(s, 10, 64)
+	i, err := strconv.ParseUint(s, 10, 32)
 	if err != nil {
 		return nil, err
 	}
-	return net.IPv4(byte(i>>24), byte(i>>16), byte(i>>8), byte(i)), nil
+	return net.IP{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)}, nil
 }
 
-func ftime(s string) string {
-	i, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		return ""
+func strlongs2ip(words []string) (net.IP, error) {
+	ip := make(net.IP, net.IPv6len)
+	for i, w := range words {
+		n, err := strconv.ParseUint(w, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(ip[i*4:], uint32(n))
 	}
-	return time.Unix(i, 0).UTC().Format(time.RFC3339)
+	return ip, nil
+}
+
+func ftime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
 }
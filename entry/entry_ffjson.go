@@ -38,6 +38,31 @@ func (mj *GeoIPEntry) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 		fflib.WriteJsonString(buf, string(mj.IsoCode))
 		buf.WriteByte(',')
 	}
+	if len(mj.CityName) != 0 {
+		buf.WriteString(`"city_name":`)
+		fflib.WriteJsonString(buf, string(mj.CityName))
+		buf.WriteByte(',')
+	}
+	if len(mj.SubdivisionIsoCode) != 0 {
+		buf.WriteString(`"subdivision_iso_code":`)
+		fflib.WriteJsonString(buf, string(mj.SubdivisionIsoCode))
+		buf.WriteByte(',')
+	}
+	if len(mj.SubdivisionName) != 0 {
+		buf.WriteString(`"subdivision_name":`)
+		fflib.WriteJsonString(buf, string(mj.SubdivisionName))
+		buf.WriteByte(',')
+	}
+	if len(mj.PostalCode) != 0 {
+		buf.WriteString(`"postal_code":`)
+		fflib.WriteJsonString(buf, string(mj.PostalCode))
+		buf.WriteByte(',')
+	}
+	if mj.AccuracyRadius != 0 {
+		buf.WriteString(`"accuracy_radius":`)
+		fflib.FormatBits2(buf, uint64(mj.AccuracyRadius), 10, false)
+		buf.WriteByte(',')
+	}
 	if mj.Latitude != 0 {
 		buf.WriteString(`"latitude":`)
 		fflib.AppendFloat(buf, float64(mj.Latitude), 'g', -1, 64)
@@ -59,6 +84,16 @@ const (
 
 	ffj_t_GeoIPEntry_IsoCode
 
+	ffj_t_GeoIPEntry_CityName
+
+	ffj_t_GeoIPEntry_SubdivisionIsoCode
+
+	ffj_t_GeoIPEntry_SubdivisionName
+
+	ffj_t_GeoIPEntry_PostalCode
+
+	ffj_t_GeoIPEntry_AccuracyRadius
+
 	ffj_t_GeoIPEntry_Latitude
 
 	ffj_t_GeoIPEntry_Longitude
@@ -66,6 +101,16 @@ const (
 
 var ffj_key_GeoIPEntry_IsoCode = []byte("iso_code")
 
+var ffj_key_GeoIPEntry_CityName = []byte("city_name")
+
+var ffj_key_GeoIPEntry_SubdivisionIsoCode = []byte("subdivision_iso_code")
+
+var ffj_key_GeoIPEntry_SubdivisionName = []byte("subdivision_name")
+
+var ffj_key_GeoIPEntry_PostalCode = []byte("postal_code")
+
+var ffj_key_GeoIPEntry_AccuracyRadius = []byte("accuracy_radius")
+
 var ffj_key_GeoIPEntry_Latitude = []byte("latitude")
 
 var ffj_key_GeoIPEntry_Longitude = []byte("longitude")
@@ -129,6 +174,22 @@ mainparse:
 			} else {
 				switch kn[0] {
 
+				case 'a':
+
+					if bytes.Equal(ffj_key_GeoIPEntry_AccuracyRadius, kn) {
+						currentKey = ffj_t_GeoIPEntry_AccuracyRadius
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'c':
+
+					if bytes.Equal(ffj_key_GeoIPEntry_CityName, kn) {
+						currentKey = ffj_t_GeoIPEntry_CityName
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
 				case 'i':
 
 					if bytes.Equal(ffj_key_GeoIPEntry_IsoCode, kn) {
@@ -150,6 +211,27 @@ mainparse:
 						goto mainparse
 					}
 
+				case 'p':
+
+					if bytes.Equal(ffj_key_GeoIPEntry_PostalCode, kn) {
+						currentKey = ffj_t_GeoIPEntry_PostalCode
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 's':
+
+					if bytes.Equal(ffj_key_GeoIPEntry_SubdivisionIsoCode, kn) {
+						currentKey = ffj_t_GeoIPEntry_SubdivisionIsoCode
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_GeoIPEntry_SubdivisionName, kn) {
+						currentKey = ffj_t_GeoIPEntry_SubdivisionName
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
 				}
 
 				if fflib.SimpleLetterEqualFold(ffj_key_GeoIPEntry_Longitude, kn) {
@@ -164,6 +246,36 @@ mainparse:
 					goto mainparse
 				}
 
+				if fflib.EqualFoldRight(ffj_key_GeoIPEntry_AccuracyRadius, kn) {
+					currentKey = ffj_t_GeoIPEntry_AccuracyRadius
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_GeoIPEntry_PostalCode, kn) {
+					currentKey = ffj_t_GeoIPEntry_PostalCode
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_GeoIPEntry_SubdivisionName, kn) {
+					currentKey = ffj_t_GeoIPEntry_SubdivisionName
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_GeoIPEntry_SubdivisionIsoCode, kn) {
+					currentKey = ffj_t_GeoIPEntry_SubdivisionIsoCode
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.AsciiEqualFold(ffj_key_GeoIPEntry_CityName, kn) {
+					currentKey = ffj_t_GeoIPEntry_CityName
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
 				if fflib.EqualFoldRight(ffj_key_GeoIPEntry_IsoCode, kn) {
 					currentKey = ffj_t_GeoIPEntry_IsoCode
 					state = fflib.FFParse_want_colon
@@ -190,6 +302,21 @@ mainparse:
 				case ffj_t_GeoIPEntry_IsoCode:
 					goto handle_IsoCode
 
+				case ffj_t_GeoIPEntry_CityName:
+					goto handle_CityName
+
+				case ffj_t_GeoIPEntry_SubdivisionIsoCode:
+					goto handle_SubdivisionIsoCode
+
+				case ffj_t_GeoIPEntry_SubdivisionName:
+					goto handle_SubdivisionName
+
+				case ffj_t_GeoIPEntry_PostalCode:
+					goto handle_PostalCode
+
+				case ffj_t_GeoIPEntry_AccuracyRadius:
+					goto handle_AccuracyRadius
+
 				case ffj_t_GeoIPEntry_Latitude:
 					goto handle_Latitude
 
@@ -236,6 +363,140 @@ handle_IsoCode:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
+handle_CityName:
+
+	/* handler: uj.CityName type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.CityName = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SubdivisionIsoCode:
+
+	/* handler: uj.SubdivisionIsoCode type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.SubdivisionIsoCode = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SubdivisionName:
+
+	/* handler: uj.SubdivisionName type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.SubdivisionName = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_PostalCode:
+
+	/* handler: uj.PostalCode type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.PostalCode = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_AccuracyRadius:
+
+	/* handler: uj.AccuracyRadius type=uint16 kind=uint16 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint16", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 16)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.AccuracyRadius = uint16(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
 handle_Latitude:
 
 	/* handler: uj.Latitude type=float64 kind=float64 quoted=false*/
@@ -310,9 +571,9 @@ tokerror:
 	}
 	panic("ffjson-generated: unreachable, please report bug.")
 done:
+
 	return nil
 }
-
 func (mj *NfdumpEntry) MarshalJSON() ([]byte, error) {
 	var buf fflib.Buffer
 	if mj == nil {
@@ -337,24 +598,69 @@ func (mj *NfdumpEntry) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	buf.WriteString(`{ "host":`)
 	fflib.WriteJsonString(buf, string(mj.Host))
 	buf.WriteString(`,"in_bytes":`)
-	fflib.WriteJsonString(buf, string(mj.InBytes))
+	fflib.FormatBits2(buf, uint64(mj.InBytes), 10, false)
 	buf.WriteString(`,"in_pkts":`)
-	fflib.WriteJsonString(buf, string(mj.InPkts))
-	buf.WriteString(`,"ipv4_src_addr":`)
-	fflib.WriteJsonString(buf, string(mj.Ipv4SrcAddr))
-	buf.WriteString(`,"ipv4_dst_addr":`)
-	fflib.WriteJsonString(buf, string(mj.Ipv4DstAddr))
-	buf.WriteString(`,"protocol":`)
-	fflib.WriteJsonString(buf, string(mj.Protocol))
+	fflib.FormatBits2(buf, uint64(mj.InPkts), 10, false)
+	buf.WriteByte(',')
+	if len(mj.Ipv4SrcAddr) != 0 {
+		buf.WriteString(`"ipv4_src_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv4SrcAddr))
+		buf.WriteByte(',')
+	}
+	if len(mj.Ipv4DstAddr) != 0 {
+		buf.WriteString(`"ipv4_dst_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv4DstAddr))
+		buf.WriteByte(',')
+	}
+	if len(mj.Ipv6SrcAddr) != 0 {
+		buf.WriteString(`"ipv6_src_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv6SrcAddr))
+		buf.WriteByte(',')
+	}
+	if len(mj.Ipv6DstAddr) != 0 {
+		buf.WriteString(`"ipv6_dst_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv6DstAddr))
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"protocol":`)
+	fflib.FormatBits2(buf, uint64(mj.Protocol), 10, false)
 	buf.WriteString(`,"l4_src_port":`)
-	fflib.WriteJsonString(buf, string(mj.L4SrcPort))
+	fflib.FormatBits2(buf, uint64(mj.L4SrcPort), 10, false)
 	buf.WriteString(`,"l4_dst_port":`)
-	fflib.WriteJsonString(buf, string(mj.L4DstPort))
+	fflib.FormatBits2(buf, uint64(mj.L4DstPort), 10, false)
+	buf.WriteString(`,"src_as":`)
+	fflib.FormatBits2(buf, uint64(mj.SrcAs), 10, false)
+	buf.WriteString(`,"dst_as":`)
+	fflib.FormatBits2(buf, uint64(mj.DstAs), 10, false)
+	buf.WriteByte(',')
+	if len(mj.SrcAsOrg) != 0 {
+		buf.WriteString(`"src_asn_org":`)
+		fflib.WriteJsonString(buf, string(mj.SrcAsOrg))
+		buf.WriteByte(',')
+	}
+	if len(mj.DstAsOrg) != 0 {
+		buf.WriteString(`"dst_asn_org":`)
+		fflib.WriteJsonString(buf, string(mj.DstAsOrg))
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"input_snmp":`)
+	fflib.FormatBits2(buf, uint64(mj.InputSnmp), 10, false)
+	buf.WriteString(`,"output_snmp":`)
+	fflib.FormatBits2(buf, uint64(mj.OutputSnmp), 10, false)
+	buf.WriteString(`,"tcp_flags":`)
+	fflib.FormatBits2(buf, uint64(mj.TcpFlags), 10, false)
+	buf.WriteString(`,"src_tos":`)
+	fflib.FormatBits2(buf, uint64(mj.SrcTos), 10, false)
 	buf.WriteString(`,"first_switched":`)
 	fflib.WriteJsonString(buf, string(mj.FirstSwitched))
 	buf.WriteString(`,"last_switched":`)
 	fflib.WriteJsonString(buf, string(mj.LastSwitched))
 	buf.WriteByte(',')
+	if mj.SamplingRate != 0 {
+		buf.WriteString(`"sampling_rate":`)
+		fflib.FormatBits2(buf, uint64(mj.SamplingRate), 10, false)
+		buf.WriteByte(',')
+	}
 	if mj.GeoIPSrc != nil {
 		if true {
 			buf.WriteString(`"geoip_src":`)
@@ -404,16 +710,38 @@ const (
 
 	ffj_t_NfdumpEntry_Ipv4DstAddr
 
+	ffj_t_NfdumpEntry_Ipv6SrcAddr
+
+	ffj_t_NfdumpEntry_Ipv6DstAddr
+
 	ffj_t_NfdumpEntry_Protocol
 
 	ffj_t_NfdumpEntry_L4SrcPort
 
 	ffj_t_NfdumpEntry_L4DstPort
 
+	ffj_t_NfdumpEntry_SrcAs
+
+	ffj_t_NfdumpEntry_DstAs
+
+	ffj_t_NfdumpEntry_SrcAsOrg
+
+	ffj_t_NfdumpEntry_DstAsOrg
+
+	ffj_t_NfdumpEntry_InputSnmp
+
+	ffj_t_NfdumpEntry_OutputSnmp
+
+	ffj_t_NfdumpEntry_TcpFlags
+
+	ffj_t_NfdumpEntry_SrcTos
+
 	ffj_t_NfdumpEntry_FirstSwitched
 
 	ffj_t_NfdumpEntry_LastSwitched
 
+	ffj_t_NfdumpEntry_SamplingRate
+
 	ffj_t_NfdumpEntry_GeoIPSrc
 
 	ffj_t_NfdumpEntry_GeoIPDst
@@ -429,16 +757,38 @@ var ffj_key_NfdumpEntry_Ipv4SrcAddr = []byte("ipv4_src_addr")
 
 var ffj_key_NfdumpEntry_Ipv4DstAddr = []byte("ipv4_dst_addr")
 
+var ffj_key_NfdumpEntry_Ipv6SrcAddr = []byte("ipv6_src_addr")
+
+var ffj_key_NfdumpEntry_Ipv6DstAddr = []byte("ipv6_dst_addr")
+
 var ffj_key_NfdumpEntry_Protocol = []byte("protocol")
 
 var ffj_key_NfdumpEntry_L4SrcPort = []byte("l4_src_port")
 
 var ffj_key_NfdumpEntry_L4DstPort = []byte("l4_dst_port")
 
+var ffj_key_NfdumpEntry_SrcAs = []byte("src_as")
+
+var ffj_key_NfdumpEntry_DstAs = []byte("dst_as")
+
+var ffj_key_NfdumpEntry_SrcAsOrg = []byte("src_asn_org")
+
+var ffj_key_NfdumpEntry_DstAsOrg = []byte("dst_asn_org")
+
+var ffj_key_NfdumpEntry_InputSnmp = []byte("input_snmp")
+
+var ffj_key_NfdumpEntry_OutputSnmp = []byte("output_snmp")
+
+var ffj_key_NfdumpEntry_TcpFlags = []byte("tcp_flags")
+
+var ffj_key_NfdumpEntry_SrcTos = []byte("src_tos")
+
 var ffj_key_NfdumpEntry_FirstSwitched = []byte("first_switched")
 
 var ffj_key_NfdumpEntry_LastSwitched = []byte("last_switched")
 
+var ffj_key_NfdumpEntry_SamplingRate = []byte("sampling_rate")
+
 var ffj_key_NfdumpEntry_GeoIPSrc = []byte("geoip_src")
 
 var ffj_key_NfdumpEntry_GeoIPDst = []byte("geoip_dst")
@@ -502,6 +852,19 @@ mainparse:
 			} else {
 				switch kn[0] {
 
+				case 'd':
+
+					if bytes.Equal(ffj_key_NfdumpEntry_DstAs, kn) {
+						currentKey = ffj_t_NfdumpEntry_DstAs
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntry_DstAsOrg, kn) {
+						currentKey = ffj_t_NfdumpEntry_DstAsOrg
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
 				case 'f':
 
 					if bytes.Equal(ffj_key_NfdumpEntry_FirstSwitched, kn) {
@@ -552,6 +915,21 @@ mainparse:
 						currentKey = ffj_t_NfdumpEntry_Ipv4DstAddr
 						state = fflib.FFParse_want_colon
 						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntry_Ipv6SrcAddr, kn) {
+						currentKey = ffj_t_NfdumpEntry_Ipv6SrcAddr
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntry_Ipv6DstAddr, kn) {
+						currentKey = ffj_t_NfdumpEntry_Ipv6DstAddr
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntry_InputSnmp, kn) {
+						currentKey = ffj_t_NfdumpEntry_InputSnmp
+						state = fflib.FFParse_want_colon
+						goto mainparse
 					}
 
 				case 'l':
@@ -572,6 +950,14 @@ mainparse:
 						goto mainparse
 					}
 
+				case 'o':
+
+					if bytes.Equal(ffj_key_NfdumpEntry_OutputSnmp, kn) {
+						currentKey = ffj_t_NfdumpEntry_OutputSnmp
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
 				case 'p':
 
 					if bytes.Equal(ffj_key_NfdumpEntry_Protocol, kn) {
@@ -580,20 +966,57 @@ mainparse:
 						goto mainparse
 					}
 
-				}
+				case 's':
 
-				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_GeoIPDst, kn) {
-					currentKey = ffj_t_NfdumpEntry_GeoIPDst
-					state = fflib.FFParse_want_colon
-					goto mainparse
-				}
+					if bytes.Equal(ffj_key_NfdumpEntry_SrcAs, kn) {
+						currentKey = ffj_t_NfdumpEntry_SrcAs
+						state = fflib.FFParse_want_colon
+						goto mainparse
 
-				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_GeoIPSrc, kn) {
+					} else if bytes.Equal(ffj_key_NfdumpEntry_SrcAsOrg, kn) {
+						currentKey = ffj_t_NfdumpEntry_SrcAsOrg
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntry_SrcTos, kn) {
+						currentKey = ffj_t_NfdumpEntry_SrcTos
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntry_SamplingRate, kn) {
+						currentKey = ffj_t_NfdumpEntry_SamplingRate
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 't':
+
+					if bytes.Equal(ffj_key_NfdumpEntry_TcpFlags, kn) {
+						currentKey = ffj_t_NfdumpEntry_TcpFlags
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_GeoIPDst, kn) {
+					currentKey = ffj_t_NfdumpEntry_GeoIPDst
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_GeoIPSrc, kn) {
 					currentKey = ffj_t_NfdumpEntry_GeoIPSrc
 					state = fflib.FFParse_want_colon
 					goto mainparse
 				}
 
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_SamplingRate, kn) {
+					currentKey = ffj_t_NfdumpEntry_SamplingRate
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
 				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_LastSwitched, kn) {
 					currentKey = ffj_t_NfdumpEntry_LastSwitched
 					state = fflib.FFParse_want_colon
@@ -606,6 +1029,54 @@ mainparse:
 					goto mainparse
 				}
 
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_SrcTos, kn) {
+					currentKey = ffj_t_NfdumpEntry_SrcTos
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_TcpFlags, kn) {
+					currentKey = ffj_t_NfdumpEntry_TcpFlags
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_OutputSnmp, kn) {
+					currentKey = ffj_t_NfdumpEntry_OutputSnmp
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_InputSnmp, kn) {
+					currentKey = ffj_t_NfdumpEntry_InputSnmp
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_DstAsOrg, kn) {
+					currentKey = ffj_t_NfdumpEntry_DstAsOrg
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_SrcAsOrg, kn) {
+					currentKey = ffj_t_NfdumpEntry_SrcAsOrg
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_DstAs, kn) {
+					currentKey = ffj_t_NfdumpEntry_DstAs
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_SrcAs, kn) {
+					currentKey = ffj_t_NfdumpEntry_SrcAs
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
 				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_L4DstPort, kn) {
 					currentKey = ffj_t_NfdumpEntry_L4DstPort
 					state = fflib.FFParse_want_colon
@@ -624,6 +1095,18 @@ mainparse:
 					goto mainparse
 				}
 
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_Ipv6DstAddr, kn) {
+					currentKey = ffj_t_NfdumpEntry_Ipv6DstAddr
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_Ipv6SrcAddr, kn) {
+					currentKey = ffj_t_NfdumpEntry_Ipv6SrcAddr
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
 				if fflib.EqualFoldRight(ffj_key_NfdumpEntry_Ipv4DstAddr, kn) {
 					currentKey = ffj_t_NfdumpEntry_Ipv4DstAddr
 					state = fflib.FFParse_want_colon
@@ -686,6 +1169,12 @@ mainparse:
 				case ffj_t_NfdumpEntry_Ipv4DstAddr:
 					goto handle_Ipv4DstAddr
 
+				case ffj_t_NfdumpEntry_Ipv6SrcAddr:
+					goto handle_Ipv6SrcAddr
+
+				case ffj_t_NfdumpEntry_Ipv6DstAddr:
+					goto handle_Ipv6DstAddr
+
 				case ffj_t_NfdumpEntry_Protocol:
 					goto handle_Protocol
 
@@ -695,12 +1184,39 @@ mainparse:
 				case ffj_t_NfdumpEntry_L4DstPort:
 					goto handle_L4DstPort
 
+				case ffj_t_NfdumpEntry_SrcAs:
+					goto handle_SrcAs
+
+				case ffj_t_NfdumpEntry_DstAs:
+					goto handle_DstAs
+
+				case ffj_t_NfdumpEntry_SrcAsOrg:
+					goto handle_SrcAsOrg
+
+				case ffj_t_NfdumpEntry_DstAsOrg:
+					goto handle_DstAsOrg
+
+				case ffj_t_NfdumpEntry_InputSnmp:
+					goto handle_InputSnmp
+
+				case ffj_t_NfdumpEntry_OutputSnmp:
+					goto handle_OutputSnmp
+
+				case ffj_t_NfdumpEntry_TcpFlags:
+					goto handle_TcpFlags
+
+				case ffj_t_NfdumpEntry_SrcTos:
+					goto handle_SrcTos
+
 				case ffj_t_NfdumpEntry_FirstSwitched:
 					goto handle_FirstSwitched
 
 				case ffj_t_NfdumpEntry_LastSwitched:
 					goto handle_LastSwitched
 
+				case ffj_t_NfdumpEntry_SamplingRate:
+					goto handle_SamplingRate
+
 				case ffj_t_NfdumpEntry_GeoIPSrc:
 					goto handle_GeoIPSrc
 
@@ -749,23 +1265,57 @@ handle_Host:
 
 handle_InBytes:
 
-	/* handler: uj.InBytes type=string kind=string quoted=false*/
+	/* handler: uj.InBytes type=uint64 kind=uint64 quoted=false*/
 
 	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint64", tok))
+		}
+	}
 
-		{
-			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
-				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 64)
+
+			if err != nil {
+				return fs.WrapErr(err)
 			}
+
+			uj.InBytes = uint64(tval)
+
 		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_InPkts:
+
+	/* handler: uj.InPkts type=uint64 kind=uint64 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint64", tok))
+		}
+	}
+
+	{
 
 		if tok == fflib.FFTok_null {
 
 		} else {
 
-			outBuf := fs.Output.Bytes()
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 64)
 
-			uj.InBytes = string(string(outBuf))
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.InPkts = uint64(tval)
 
 		}
 	}
@@ -773,9 +1323,9 @@ handle_InBytes:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
-handle_InPkts:
+handle_Ipv4SrcAddr:
 
-	/* handler: uj.InPkts type=string kind=string quoted=false*/
+	/* handler: uj.Ipv4SrcAddr type=string kind=string quoted=false*/
 
 	{
 
@@ -791,7 +1341,7 @@ handle_InPkts:
 
 			outBuf := fs.Output.Bytes()
 
-			uj.InPkts = string(string(outBuf))
+			uj.Ipv4SrcAddr = string(string(outBuf))
 
 		}
 	}
@@ -799,9 +1349,9 @@ handle_InPkts:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
-handle_Ipv4SrcAddr:
+handle_Ipv4DstAddr:
 
-	/* handler: uj.Ipv4SrcAddr type=string kind=string quoted=false*/
+	/* handler: uj.Ipv4DstAddr type=string kind=string quoted=false*/
 
 	{
 
@@ -817,7 +1367,7 @@ handle_Ipv4SrcAddr:
 
 			outBuf := fs.Output.Bytes()
 
-			uj.Ipv4SrcAddr = string(string(outBuf))
+			uj.Ipv4DstAddr = string(string(outBuf))
 
 		}
 	}
@@ -825,9 +1375,9 @@ handle_Ipv4SrcAddr:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
-handle_Ipv4DstAddr:
+handle_Ipv6SrcAddr:
 
-	/* handler: uj.Ipv4DstAddr type=string kind=string quoted=false*/
+	/* handler: uj.Ipv6SrcAddr type=string kind=string quoted=false*/
 
 	{
 
@@ -843,7 +1393,7 @@ handle_Ipv4DstAddr:
 
 			outBuf := fs.Output.Bytes()
 
-			uj.Ipv4DstAddr = string(string(outBuf))
+			uj.Ipv6SrcAddr = string(string(outBuf))
 
 		}
 	}
@@ -851,9 +1401,9 @@ handle_Ipv4DstAddr:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
-handle_Protocol:
+handle_Ipv6DstAddr:
 
-	/* handler: uj.Protocol type=string kind=string quoted=false*/
+	/* handler: uj.Ipv6DstAddr type=string kind=string quoted=false*/
 
 	{
 
@@ -869,7 +1419,37 @@ handle_Protocol:
 
 			outBuf := fs.Output.Bytes()
 
-			uj.Protocol = string(string(outBuf))
+			uj.Ipv6DstAddr = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_Protocol:
+
+	/* handler: uj.Protocol type=uint8 kind=uint8 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint8", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 8)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.Protocol = uint8(tval)
 
 		}
 	}
@@ -879,7 +1459,127 @@ handle_Protocol:
 
 handle_L4SrcPort:
 
-	/* handler: uj.L4SrcPort type=string kind=string quoted=false*/
+	/* handler: uj.L4SrcPort type=uint16 kind=uint16 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint16", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 16)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.L4SrcPort = uint16(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_L4DstPort:
+
+	/* handler: uj.L4DstPort type=uint16 kind=uint16 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint16", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 16)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.L4DstPort = uint16(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SrcAs:
+
+	/* handler: uj.SrcAs type=uint32 kind=uint32 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint32", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 32)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.SrcAs = uint32(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_DstAs:
+
+	/* handler: uj.DstAs type=uint32 kind=uint32 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint32", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 32)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.DstAs = uint32(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SrcAsOrg:
+
+	/* handler: uj.SrcAsOrg type=string kind=string quoted=false*/
 
 	{
 
@@ -895,7 +1595,7 @@ handle_L4SrcPort:
 
 			outBuf := fs.Output.Bytes()
 
-			uj.L4SrcPort = string(string(outBuf))
+			uj.SrcAsOrg = string(string(outBuf))
 
 		}
 	}
@@ -903,9 +1603,9 @@ handle_L4SrcPort:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
-handle_L4DstPort:
+handle_DstAsOrg:
 
-	/* handler: uj.L4DstPort type=string kind=string quoted=false*/
+	/* handler: uj.DstAsOrg type=string kind=string quoted=false*/
 
 	{
 
@@ -921,7 +1621,127 @@ handle_L4DstPort:
 
 			outBuf := fs.Output.Bytes()
 
-			uj.L4DstPort = string(string(outBuf))
+			uj.DstAsOrg = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_InputSnmp:
+
+	/* handler: uj.InputSnmp type=uint32 kind=uint32 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint32", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 32)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.InputSnmp = uint32(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_OutputSnmp:
+
+	/* handler: uj.OutputSnmp type=uint32 kind=uint32 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint32", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 32)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.OutputSnmp = uint32(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_TcpFlags:
+
+	/* handler: uj.TcpFlags type=uint8 kind=uint8 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint8", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 8)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.TcpFlags = uint8(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SrcTos:
+
+	/* handler: uj.SrcTos type=uint8 kind=uint8 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint8", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 8)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.SrcTos = uint8(tval)
 
 		}
 	}
@@ -981,6 +1801,36 @@ handle_LastSwitched:
 	state = fflib.FFParse_after_value
 	goto mainparse
 
+handle_SamplingRate:
+
+	/* handler: uj.SamplingRate type=uint32 kind=uint32 quoted=false*/
+
+	{
+		if tok != fflib.FFTok_integer && tok != fflib.FFTok_null {
+			return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for uint32", tok))
+		}
+	}
+
+	{
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			tval, err := fflib.ParseUint(fs.Output.Bytes(), 10, 32)
+
+			if err != nil {
+				return fs.WrapErr(err)
+			}
+
+			uj.SamplingRate = uint32(tval)
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
 handle_GeoIPSrc:
 
 	/* handler: uj.GeoIPSrc type=entry.GeoIPEntry kind=struct quoted=false*/
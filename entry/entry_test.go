@@ -1,34 +1,69 @@
 package entry
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/geoip"
+)
 
 func TestMarshal(t *testing.T) {
 	var tests = []struct {
-		host  string
-		input string
-		expec string
+		host   string
+		schema int
+		input  string
+		expec  string
 	}{
 		{
 			"localhost",
+			SchemaV1,
 			"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
-			`{ "host":"localhost","in_bytes":"5256","in_pkts":"10","ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":"6","l4_src_port":"443","l4_dst_port":"57145","first_switched":"2016-05-16T19:10:44Z","last_switched":"2016-05-16T19:10:55Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`,
+			`{ "host":"localhost","in_bytes":"5256","in_pkts":"10","ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":"6","l4_src_port":"443","l4_dst_port":"57145","src_as":"64512","dst_as":"12357","input_snmp":"39","output_snmp":"41","tcp_flags":"0","src_tos":"0","first_switched":"2016-05-16T19:10:44.692Z","last_switched":"2016-05-16T19:10:55.188Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`,
 		},
 		{
 			"different.hostname.tld",
+			SchemaV1,
 			"2|1463425829|17|1463425834|5|6|0|0|0|2386192149|179|0|0|0|3641448481|11482|0|0|39|0|24|0|2|99",
-			`{ "host":"different.hostname.tld","in_bytes":"99","in_pkts":"2","ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":"6","l4_src_port":"179","l4_dst_port":"11482","first_switched":"2016-05-16T19:10:29Z","last_switched":"2016-05-16T19:10:34Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`,
+			`{ "host":"different.hostname.tld","in_bytes":"99","in_pkts":"2","ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":"6","l4_src_port":"179","l4_dst_port":"11482","src_as":"0","dst_as":"0","input_snmp":"39","output_snmp":"0","tcp_flags":"24","src_tos":"0","first_switched":"2016-05-16T19:10:29.017Z","last_switched":"2016-05-16T19:10:34.005Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`,
+		},
+		{
+			"localhost",
+			SchemaV1,
+			"10|1463425844|692|1463425855|188|6|536954976|1214251008|0|34952|443|704648272|1073940490|0|8206|57145|15169|15169|39|41|0|0|10|5256",
+			`{ "host":"localhost","in_bytes":"5256","in_pkts":"10","ipv6_src_addr":"2001:4860:4860::8888","ipv6_dst_addr":"2a00:1450:4003:80a::200e","protocol":"6","l4_src_port":"443","l4_dst_port":"57145","src_as":"15169","dst_as":"15169","input_snmp":"39","output_snmp":"41","tcp_flags":"0","src_tos":"0","first_switched":"2016-05-16T19:10:44.692Z","last_switched":"2016-05-16T19:10:55.188Z","geoip_src":{ "iso_code":"US"},"geoip_dst":{ "iso_code":"IE"}}`,
+		},
+		{
+			"localhost",
+			SchemaV2,
+			"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+			`{ "host":"localhost","in_bytes":5256,"in_pkts":10,"ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":6,"l4_src_port":443,"l4_dst_port":57145,"src_as":64512,"dst_as":12357,"input_snmp":39,"output_snmp":41,"tcp_flags":0,"src_tos":0,"first_switched":"2016-05-16T19:10:44.692Z","last_switched":"2016-05-16T19:10:55.188Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`,
 		},
 	}
 	for _, tt := range tests {
-		Hostname = tt.host
+		*Hostname = tt.host
+		*Schema = tt.schema
 		entry, _ := NewNfdumpEntry(tt.input)
-		actual, _ := entry.MarshalJSON()
+		actual, _ := entry.Marshal()
 		if string(actual) != tt.expec {
 			t.Errorf("marshal: expected %s, actual %s", tt.expec, actual)
 		}
 	}
 }
 
+func TestUnrecognized(t *testing.T) {
+	var tests = []string{
+		"2|1463425844|692",
+		"2|1463425844|692|1463425855|188|tcp|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+		"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|70000|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+	}
+	for _, tt := range tests {
+		if _, err := NewNfdumpEntry(tt); err == nil {
+			t.Errorf("entry(%s): expected error", tt)
+		}
+	}
+}
+
 func TestIp(t *testing.T) {
 	var tests = []struct {
 		input string
@@ -49,18 +84,241 @@ func TestIp(t *testing.T) {
 	}
 }
 
+func TestIp6(t *testing.T) {
+	var tests = []struct {
+		input []string
+		expec string
+	}{
+		{[]string{"536954976", "1214251008", "0", "34952"}, "2001:4860:4860::8888"},
+		{[]string{"704648272", "1073940490", "0", "8206"}, "2a00:1450:4003:80a::200e"},
+	}
+	for _, tt := range tests {
+		actual, err := strlongs2ip(tt.input)
+		if err != nil {
+			t.Error(err)
+		}
+		ipv6 := actual.String()
+		if ipv6 != tt.expec {
+			t.Errorf("ip(%v): expected %s, actual %s", tt.input, tt.expec, ipv6)
+		}
+	}
+}
+
 func TestFtime(t *testing.T) {
 	var tests = []struct {
 		input string
+		msec  string
 		expec string
 	}{
-		{"1463958401", "2016-05-22T23:06:41Z"},
-		{"1234567890", "2009-02-13T23:31:30Z"},
+		{"1463958401", "0", "2016-05-22T23:06:41.000Z"},
+		{"1234567890", "123", "2009-02-13T23:31:30.123Z"},
 	}
 	for _, tt := range tests {
-		actual := ftime(tt.input)
+		f := fieldParser{parts: []string{tt.input, tt.msec}}
+		actual := ftime(f.time(0, 1))
+		if f.err != nil {
+			t.Error(f.err)
+		}
 		if actual != tt.expec {
-			t.Errorf("finput(%s): expected %s, actual %s", tt.input, tt.expec, actual)
+			t.Errorf("finput(%s, %s): expected %s, actual %s", tt.input, tt.msec, tt.expec, actual)
+		}
+	}
+}
+
+func TestFlowString(t *testing.T) {
+	var tests = []string{
+		"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+		"10|1463425844|692|1463425855|188|6|536954976|1214251008|0|34952|443|704648272|1073940490|0|8206|57145|15169|15169|39|41|0|0|10|5256",
+	}
+	for _, tt := range tests {
+		f, err := ParseFlow(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := f.String(); actual != tt {
+			t.Errorf("flow(%s): expected %s, actual %s", tt, tt, actual)
+		}
+	}
+}
+
+func TestMarshalSampled(t *testing.T) {
+	var tests = []struct {
+		schema int
+		expec  string
+	}{
+		{SchemaV1, `"sampling_rate":"512",`},
+		{SchemaV2, `"sampling_rate":512,`},
+	}
+	f, err := ParseFlow("2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|512|768000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SamplingRate = 512
+	for _, tt := range tests {
+		*Schema = tt.schema
+		actual, _ := NewFlowEntry(f).Marshal()
+		if !strings.Contains(string(actual), tt.expec) {
+			t.Errorf("marshal(%d): expected %s in %s", tt.schema, tt.expec, actual)
+		}
+	}
+}
+
+func TestMarshalCity(t *testing.T) {
+	if err := geoip.Open("../geoip/testdata/city.mmdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer geoip.Open()
+
+	*Schema = SchemaV2
+	entry, err := NewNfdumpEntry("2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, _ := entry.Marshal()
+	expec := `"geoip_src":{ "iso_code":"CA","city_name":"Burnaby","subdivision_iso_code":"BC","subdivision_name":"British Columbia","postal_code":"V5A","accuracy_radius":20,"latitude":49.2788,"longitude":-122.9197},"geoip_dst":{}}`
+	if !strings.HasSuffix(string(actual), expec) {
+		t.Errorf("marshal: expected %s in %s", expec, actual)
+	}
+}
+
+func TestMarshalASN(t *testing.T) {
+	if err := geoip.OpenASN("../geoip/testdata/asn.mmdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer geoip.OpenASN("")
+
+	var tests = []struct {
+		input string
+		expec string
+	}{
+		// The exporter left the AS numbers as zero.
+		{
+			"2|1463425829|17|1463425834|5|6|0|0|0|2386192149|179|0|0|0|3641448481|11482|0|0|39|0|24|0|2|99",
+			`"src_as":271,"dst_as":12357,"src_asn_org":"BCNET","dst_asn_org":"COMUNITEL",`,
+		},
+		// The exporter disagrees on the source AS number.
+		{
+			"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+			`"src_as":64512,"dst_as":12357,"dst_asn_org":"COMUNITEL",`,
+		},
+	}
+	*Schema = SchemaV2
+	for _, tt := range tests {
+		entry, err := NewNfdumpEntry(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, _ := entry.Marshal()
+		if !strings.Contains(string(actual), tt.expec) {
+			t.Errorf("marshal(%s): expected %s in %s", tt.input, tt.expec, actual)
+		}
+	}
+}
+
+func TestEnrichers(t *testing.T) {
+	if err := geoip.OpenASN("../geoip/testdata/asn.mmdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer geoip.OpenASN("")
+	defer func() { *NoGeo, *NoASN = false, false }()
+
+	var tests = []struct {
+		noGeo, noASN bool
+		expec        string
+	}{
+		{false, false, `"ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":6,"l4_src_port":179,"l4_dst_port":11482,"src_as":271,"dst_as":12357,"src_asn_org":"BCNET","dst_asn_org":"COMUNITEL","input_snmp":39,"output_snmp":0,"tcp_flags":24,"src_tos":0,"first_switched":"2016-05-16T19:10:29.017Z","last_switched":"2016-05-16T19:10:34.005Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`},
+		{true, false, `"ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":6,"l4_src_port":179,"l4_dst_port":11482,"src_as":271,"dst_as":12357,"src_asn_org":"BCNET","dst_asn_org":"COMUNITEL","input_snmp":39,"output_snmp":0,"tcp_flags":24,"src_tos":0,"first_switched":"2016-05-16T19:10:29.017Z","last_switched":"2016-05-16T19:10:34.005Z"}`},
+		{false, true, `"ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":6,"l4_src_port":179,"l4_dst_port":11482,"src_as":0,"dst_as":0,"input_snmp":39,"output_snmp":0,"tcp_flags":24,"src_tos":0,"first_switched":"2016-05-16T19:10:29.017Z","last_switched":"2016-05-16T19:10:34.005Z","geoip_src":{ "iso_code":"CA"},"geoip_dst":{ "iso_code":"ES"}}`},
+		{true, true, `"ipv4_src_addr":"142.58.103.21","ipv4_dst_addr":"217.12.24.33","protocol":6,"l4_src_port":179,"l4_dst_port":11482,"src_as":0,"dst_as":0,"input_snmp":39,"output_snmp":0,"tcp_flags":24,"src_tos":0,"first_switched":"2016-05-16T19:10:29.017Z","last_switched":"2016-05-16T19:10:34.005Z"}`},
+	}
+	*Schema = SchemaV2
+	for _, tt := range tests {
+		*NoGeo, *NoASN = tt.noGeo, tt.noASN
+		entry, err := NewNfdumpEntry("2|1463425829|17|1463425834|5|6|0|0|0|2386192149|179|0|0|0|3641448481|11482|0|0|39|0|24|0|2|99")
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, _ := entry.Marshal()
+		if !strings.HasSuffix(string(actual), tt.expec) {
+			t.Errorf("marshal(nogeo=%t, noasn=%t): expected %s, actual %s", tt.noGeo, tt.noASN, tt.expec, actual)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	var tests = []struct {
+		csv    bool
+		layout string
+		input  string
+		expec  string
+	}{
+		{
+			true,
+			CSVHeader,
+			"2016-05-16 19:10:44,2016-05-16 19:10:55,10.496,142.58.103.21,217.12.24.33,443,57145,TCP,...AP...,0,0,10,5256,0,0,39,41,64512,12357,0,0,0,0,0.0.0.0,0.0.0.0,0,0,00:00:00:00:00:00,00:00:00:00:00:00,00:00:00:00:00:00,00:00:00:00:00:00,0-0-0,0-0-0,0-0-0,0-0-0,0-0-0,0-0-0,0-0-0,0-0-0,0-0-0,0-0-0,0.000,0.000,0.000,0.0.0.0,0/0,1,1970-01-01 00:00:00.000",
+			"2|1463425844|0|1463425855|0|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|24|0|10|5256",
+		},
+		{
+			true,
+			"ts,td,sa,da,pr,ibyt,ipkt",
+			"2016-05-16 19:10:44,10.496,2001:4860:4860::8888,2a00:1450:4003:80a::200e,UDP,99,2",
+			"10|1463425844|0|1463425854|496|17|536954976|1214251008|0|34952|0|704648272|1073940490|0|8206|0|0|0|0|0|0|0|2|99",
+		},
+		{
+			false,
+			"%ts %td %pr %sap -> %dap %flg %tos %pkt %byt %fl",
+			"2016-05-16 19:10:44.692    10.496 TCP        142.58.103.21:443   ->      217.12.24.33:57145 ...AP...   0       10    5.3 M     1",
+			"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|0|0|0|0|24|0|10|5300000",
+		},
+		{
+			false,
+			"%ts %te %pr %sap -> %dap %pkt %byt",
+			"2016-05-16 19:10:29.017 2016-05-16 19:10:34.005 6 2001:4860:4860::8888.443 -> 2a00:1450:4003:80a::200e.57145 2 99",
+			"10|1463425829|17|1463425834|5|6|536954976|1214251008|0|34952|443|704648272|1073940490|0|8206|57145|0|0|0|0|0|0|2|99",
+		},
+	}
+	for _, tt := range tests {
+		var l *Layout
+		var err error
+		if tt.csv {
+			l, err = NewCSVLayout(tt.layout)
+		} else {
+			l, err = NewFmtLayout(tt.layout)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Location = time.UTC
+		f, err := l.Parse(tt.input)
+		if err != nil {
+			t.Errorf("layout(%s): %s", tt.input, err)
+			continue
+		}
+		if actual := f.String(); actual != tt.expec {
+			t.Errorf("layout(%s): expected %s, actual %s", tt.input, tt.expec, actual)
+		}
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	if _, err := NewCSVLayout("flows,bytes,packets,avg_bps,avg_pps,avg_bpp"); err == nil {
+		t.Error("layout(summary): expected error")
+	}
+	if _, err := NewFmtLayout("%ts %pr %pkt"); err == nil {
+		t.Error("layout(no addresses): expected error")
+	}
+	l, err := NewFmtLayout("%ts %sa %da %pkt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []string{
+		"Summary: total flows: 1, total bytes: 5256, total packets: 10",
+		"2016-05-16 19:10:44.692 142.58.103.21 217.12.24.33 ten",
+		"2016-05-16 19:10:44.692 2001:4860..8888 217.12.24.33 10",
+	}
+	for _, tt := range tests {
+		if _, err := l.Parse(tt); err == nil {
+			t.Errorf("layout(%s): expected error", tt)
 		}
 	}
 }
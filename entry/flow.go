@@ -0,0 +1,104 @@
+package entry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flow is a flow record, whatever the input it was read from. SrcAddr and
+// DstAddr are 4 bytes long in IPv4 flows and 16 bytes long in IPv6 flows.
+// SamplingRate is the number of packets that each packet sampled stands for,
+// or 0 when the flow was not sampled. Packets and Bytes are already scaled by
+// it.
+type Flow struct {
+	SrcAddr    net.IP
+	DstAddr    net.IP
+	First      time.Time
+	Last       time.Time
+	Protocol   uint8
+	SrcPort    uint16
+	DstPort    uint16
+	SrcAs      uint32
+	DstAs      uint32
+	InputSnmp  uint32
+	OutputSnmp uint32
+	TcpFlags   uint8
+	SrcTos     uint8
+	Packets    uint64
+	Bytes      uint64
+
+	SamplingRate uint32
+}
+
+// ParseFlow decodes a line of the nfdump pipe format.
+func ParseFlow(s string) (*Flow, error) {
+	parts := strings.Split(s, delim)
+	if len(parts) < expectedParts {
+		return nil, errors.New("Unrecognized nfdump entry")
+	}
+
+	p := fieldParser{parts: parts}
+	f := Flow{
+		First:      p.time(1, 2),
+		Last:       p.time(3, 4),
+		Protocol:   uint8(p.uint(5, 8)),
+		SrcPort:    uint16(p.uint(10, 16)),
+		DstPort:    uint16(p.uint(15, 16)),
+		SrcAs:      uint32(p.uint(16, 32)),
+		DstAs:      uint32(p.uint(17, 32)),
+		InputSnmp:  uint32(p.uint(18, 32)),
+		OutputSnmp: uint32(p.uint(19, 32)),
+		TcpFlags:   uint8(p.uint(20, 8)),
+		SrcTos:     uint8(p.uint(21, 8)),
+		Packets:    p.uint(22, 64),
+		Bytes:      p.uint(23, 64),
+	}
+	if p.err != nil {
+		return nil, errors.New("Unrecognized nfdump entry")
+	}
+
+	var err error
+	ipv6 := parts[0] != afInet
+	if f.SrcAddr, err = straddr(parts[6:10], ipv6); err != nil {
+		return nil, errors.New("Unrecognized IP address")
+	}
+	if f.DstAddr, err = straddr(parts[11:15], ipv6); err != nil {
+		return nil, errors.New("Unrecognized IP address")
+	}
+
+	return &f, nil
+}
+
+// String returns the flow in the nfdump pipe format.
+func (f *Flow) String() string {
+	af := afInet
+	if len(f.SrcAddr) == net.IPv6len {
+		af = afInet6
+	}
+	return fmt.Sprintf("%s|%d|%d|%d|%d|%d|%s|%d|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d",
+		af,
+		f.First.Unix(), f.First.Nanosecond()/int(time.Millisecond),
+		f.Last.Unix(), f.Last.Nanosecond()/int(time.Millisecond),
+		f.Protocol,
+		addrwords(f.SrcAddr), f.SrcPort,
+		addrwords(f.DstAddr), f.DstPort,
+		f.SrcAs, f.DstAs,
+		f.InputSnmp, f.OutputSnmp,
+		f.TcpFlags, f.SrcTos,
+		f.Packets, f.Bytes)
+}
+
+// addrwords returns ip as the four address fields of the pipe format.
+func addrwords(ip net.IP) string {
+	words := []string{"0", "0", "0", "0"}
+	n := len(ip) / 4
+	for i := 0; i < n; i++ {
+		words[4-n+i] = strconv.FormatUint(uint64(binary.BigEndian.Uint32(ip[i*4:])), 10)
+	}
+	return strings.Join(words, delim)
+}
@@ -0,0 +1,383 @@
+package entry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVHeader is the header of the csv output of nfdump 1.6, which gives the
+// columns of the lines that follow it.
+const CSVHeader = "ts,te,td,sa,da,sp,dp,pr,flg,fwd,stos,ipkt,ibyt,opkt,obyt,in,out,sas,das,smk,dmk,dtos,dir,nh,nhb,svln,dvln,ismc,odmc,idmc,osmc,mpls1,mpls2,mpls3,mpls4,mpls5,mpls6,mpls7,mpls8,mpls9,mpls10,cl,sl,al,ra,eng,exid,tr"
+
+// Patterns of the values printed by nfdump with a fmt: format. Numbers may be
+// scaled, e.g. "5.1 M".
+const (
+	timePattern   = `\d{4}-\d\d-\d\d \d\d:\d\d:\d\d(?:\.\d+)?`
+	numberPattern = `\d+(?:\.\d+)?(?: [KMGT])?`
+	valuePattern  = `\S+`
+)
+
+// Layouts of the times printed by nfdump, with and without milliseconds.
+const (
+	nfdumpTime     = "2006-01-02 15:04:05"
+	nfdumpTimeMsec = "2006-01-02 15:04:05.000"
+)
+
+// layoutField is an element of the csv and fmt: formats of nfdump. set
+// fills the flow with the value found, and is nil for the elements that
+// are not part of a flow, which are ignored.
+type layoutField struct {
+	pattern string
+	set     func(p *layoutParser, f *Flow, s string)
+}
+
+// layoutFields are the elements of the csv and fmt: formats, named like the
+// columns of the csv header.
+var layoutFields = map[string]layoutField{
+	"ts":   {timePattern, func(p *layoutParser, f *Flow, s string) { f.First = p.time(s) }},
+	"te":   {timePattern, func(p *layoutParser, f *Flow, s string) { f.Last = p.time(s) }},
+	"td":   {numberPattern, func(p *layoutParser, f *Flow, s string) { p.duration = p.float(s) }},
+	"sa":   {valuePattern, func(p *layoutParser, f *Flow, s string) { f.SrcAddr = p.addr(s) }},
+	"da":   {valuePattern, func(p *layoutParser, f *Flow, s string) { f.DstAddr = p.addr(s) }},
+	"sap":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.SrcAddr, f.SrcPort = p.addrPort(s) }},
+	"dap":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.DstAddr, f.DstPort = p.addrPort(s) }},
+	"sp":   {valuePattern, func(p *layoutParser, f *Flow, s string) { f.SrcPort = p.port(s) }},
+	"dp":   {valuePattern, func(p *layoutParser, f *Flow, s string) { f.DstPort = p.port(s) }},
+	"pr":   {valuePattern, func(p *layoutParser, f *Flow, s string) { f.Protocol = p.protocol(s) }},
+	"flg":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.TcpFlags = p.flags(s) }},
+	"stos": {valuePattern, func(p *layoutParser, f *Flow, s string) { f.SrcTos = uint8(p.uint(s, 8)) }},
+	"tos":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.SrcTos = uint8(p.uint(s, 8)) }},
+	"ipkt": {numberPattern, func(p *layoutParser, f *Flow, s string) { f.Packets = p.count(s) }},
+	"pkt":  {numberPattern, func(p *layoutParser, f *Flow, s string) { f.Packets = p.count(s) }},
+	"ibyt": {numberPattern, func(p *layoutParser, f *Flow, s string) { f.Bytes = p.count(s) }},
+	"byt":  {numberPattern, func(p *layoutParser, f *Flow, s string) { f.Bytes = p.count(s) }},
+	"in":   {valuePattern, func(p *layoutParser, f *Flow, s string) { f.InputSnmp = uint32(p.uint(s, 32)) }},
+	"out":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.OutputSnmp = uint32(p.uint(s, 32)) }},
+	"sas":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.SrcAs = uint32(p.uint(s, 32)) }},
+	"das":  {valuePattern, func(p *layoutParser, f *Flow, s string) { f.DstAs = uint32(p.uint(s, 32)) }},
+
+	// Numbers that may be scaled, which are not part of a flow.
+	"fl":   {numberPattern, nil},
+	"opkt": {numberPattern, nil},
+	"obyt": {numberPattern, nil},
+	"bps":  {numberPattern, nil},
+	"pps":  {numberPattern, nil},
+	"bpp":  {numberPattern, nil},
+}
+
+// protocols are the names printed by nfdump for the most common IP protocols.
+// Others are printed as numbers.
+var protocols = map[string]uint8{
+	"ICMP":  1,
+	"IGMP":  2,
+	"IPIP":  4,
+	"TCP":   6,
+	"EGP":   8,
+	"UDP":   17,
+	"IPV6":  41,
+	"RSVP":  46,
+	"GRE":   47,
+	"ESP":   50,
+	"AH":    51,
+	"ICMP6": 58,
+	"EIGRP": 88,
+	"OSPF":  89,
+	"PIM":   103,
+	"VRRP":  112,
+	"L2TP":  115,
+	"SCTP":  132,
+}
+
+// tcpFlags are the letters of the TCP flags printed by nfdump, from the most
+// significant bit.
+const tcpFlags = "CEUAPRSF"
+
+// Layout describes the lines of the csv and fmt: output formats of nfdump,
+// which unlike the pipe format may hold any subset of the fields of a flow.
+// Times are read in Location, which is the local time zone by default, like
+// nfdump prints them.
+type Layout struct {
+	Location *time.Location
+
+	re     *regexp.Regexp // nil in the csv format
+	header string         // empty in the fmt: format
+	fields []layoutField
+}
+
+// NewCSVLayout returns the layout of the lines of nfdump csv output that
+// follow header. The columns of the header that are not known are ignored.
+func NewCSVLayout(header string) (*Layout, error) {
+	names := strings.Split(header, ",")
+	l := &Layout{Location: time.Local, header: header, fields: make([]layoutField, len(names))}
+	for i, name := range names {
+		l.fields[i] = layoutFields[strings.TrimSpace(name)]
+	}
+	if err := l.check(names); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Header returns the csv header that the layout was created from, or an
+// empty string in the fmt: format.
+func (l *Layout) Header() string {
+	return l.header
+}
+
+// NewFmtLayout returns the layout of the lines printed by nfdump with
+// -o "fmt:format". The elements of format are matched like the columns of
+// the csv header, and the text around them, where any run of blanks matches
+// any other, must be found in the lines. Unknown elements are ignored, but
+// must not hold blanks.
+func NewFmtLayout(format string) (*Layout, error) {
+	l := &Layout{Location: time.Local}
+	var names []string
+	expr := `^\s*`
+	for len(format) > 0 {
+		i := strings.IndexByte(format, '%')
+		if i < 0 {
+			i = len(format)
+		}
+		expr += literalPattern(format[:i])
+		if format = format[i:]; format == "" {
+			break
+		}
+		name := fieldName(format[1:])
+		if name == "" {
+			return nil, fmt.Errorf("invalid element at %q", format)
+		}
+		format = format[1+len(name):]
+		field, ok := layoutFields[name]
+		if !ok {
+			field.pattern = valuePattern
+		}
+		l.fields = append(l.fields, field)
+		names = append(names, name)
+		expr += `\s*(` + field.pattern + `)`
+	}
+	var err error
+	if l.re, err = regexp.Compile(expr + `\s*$`); err != nil {
+		return nil, err
+	}
+	if err := l.check(names); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// fieldName returns the name of the element at the start of s, i.e. the
+// longest name of a known element, or else its letters and digits.
+func fieldName(s string) string {
+	n := 0
+	for n < len(s) && (s[n] >= 'a' && s[n] <= 'z' || s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	for i := n; i > 0; i-- {
+		if _, ok := layoutFields[s[:i]]; ok {
+			return s[:i]
+		}
+	}
+	return s[:n]
+}
+
+// literalPattern returns the pattern of the text found between elements.
+func literalPattern(s string) string {
+	var expr string
+	for i, part := range strings.Fields(s) {
+		if i > 0 || strings.TrimLeft(s, " \t") != s {
+			expr += `\s+`
+		}
+		expr += regexp.QuoteMeta(part)
+	}
+	if strings.TrimRight(s, " \t") != s {
+		expr += `\s+`
+	}
+	return expr
+}
+
+// check makes sure that the layout has the addresses and the start time of
+// the flows.
+func (l *Layout) check(names []string) error {
+	found := make(map[string]bool)
+	for _, name := range names {
+		found[strings.TrimSpace(name)] = true
+	}
+	if !found["ts"] {
+		return errors.New("layout has no ts element")
+	}
+	if !found["sa"] && !found["sap"] || !found["da"] && !found["dap"] {
+		return errors.New("layout has no sa or da element")
+	}
+	return nil
+}
+
+// Parse decodes a line. The end of the flow is its start when the layout has
+// neither te nor td.
+func (l *Layout) Parse(s string) (*Flow, error) {
+	var values []string
+	if l.re != nil {
+		m := l.re.FindStringSubmatch(s)
+		if m == nil {
+			return nil, errors.New("Unrecognized nfdump entry")
+		}
+		values = m[1:]
+	} else {
+		values = strings.Split(s, ",")
+		if len(values) < len(l.fields) {
+			return nil, errors.New("Unrecognized nfdump entry")
+		}
+	}
+
+	p := layoutParser{loc: l.Location, duration: -1}
+	var f Flow
+	for i, field := range l.fields {
+		if field.set != nil {
+			field.set(&p, &f, strings.TrimSpace(values[i]))
+		}
+	}
+	if p.err != nil {
+		return nil, errors.New("Unrecognized nfdump entry")
+	}
+	if f.SrcAddr == nil || f.DstAddr == nil {
+		return nil, errors.New("Unrecognized IP address")
+	}
+	if f.Last.IsZero() {
+		f.Last = f.First
+		if p.duration > 0 {
+			f.Last = f.First.Add(time.Duration(p.duration * float64(time.Second)))
+		}
+	}
+	return &f, nil
+}
+
+// layoutParser parses the values of a line, remembering the first error found
+// so it can be checked once.
+type layoutParser struct {
+	loc      *time.Location
+	duration float64
+	err      error
+}
+
+func (p *layoutParser) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *layoutParser) uint(s string, bitSize int) uint64 {
+	n, err := strconv.ParseUint(s, 10, bitSize)
+	if err != nil {
+		p.fail(err)
+	}
+	return n
+}
+
+func (p *layoutParser) float(s string) float64 {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		p.fail(err)
+	}
+	return n
+}
+
+// count parses a number that may be scaled by a unit prefix of 1000.
+func (p *layoutParser) count(s string) uint64 {
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return p.uint(s, 64)
+	}
+	scale := float64(1)
+	switch s[i+1:] {
+	case "K":
+		scale = 1e3
+	case "M":
+		scale = 1e6
+	case "G":
+		scale = 1e9
+	case "T":
+		scale = 1e12
+	default:
+		p.fail(fmt.Errorf("invalid number %q", s))
+	}
+	return uint64(p.float(s[:i])*scale + 0.5)
+}
+
+func (p *layoutParser) time(s string) time.Time {
+	layout := nfdumpTime
+	if strings.IndexByte(s, '.') >= 0 {
+		layout = nfdumpTimeMsec
+	}
+	t, err := time.ParseInLocation(layout, s, p.loc)
+	if err != nil {
+		p.fail(err)
+	}
+	return t
+}
+
+func (p *layoutParser) addr(s string) net.IP {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		p.fail(fmt.Errorf("invalid address %q", s))
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil && !strings.Contains(s, ":") {
+		return ip4
+	}
+	return ip
+}
+
+// addrPort parses an address followed by a port, which is separated by a
+// colon from IPv4 addresses and by a dot from IPv6 addresses.
+func (p *layoutParser) addrPort(s string) (net.IP, uint16) {
+	sep := ":"
+	if strings.Count(s, ":") > 1 {
+		sep = "."
+	}
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		p.fail(fmt.Errorf("invalid address %q", s))
+		return nil, 0
+	}
+	return p.addr(s[:i]), p.port(s[i+1:])
+}
+
+// port parses a port, or the type and code of an ICMP message, which are
+// printed as "type.code".
+func (p *layoutParser) port(s string) uint16 {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return uint16(p.uint(s[:i], 8)<<8 | p.uint(s[i+1:], 8))
+	}
+	return uint16(p.uint(s, 16))
+}
+
+func (p *layoutParser) protocol(s string) uint8 {
+	if n, ok := protocols[strings.ToUpper(s)]; ok {
+		return n
+	}
+	return uint8(p.uint(s, 8))
+}
+
+// flags parses the TCP flags, printed as letters or dots, e.g. "...AP.SF".
+func (p *layoutParser) flags(s string) uint8 {
+	if n, err := strconv.ParseUint(s, 0, 8); err == nil {
+		return uint8(n)
+	}
+	var flags uint8
+	for _, c := range s {
+		if c == '.' {
+			continue
+		}
+		i := strings.IndexRune(tcpFlags, c)
+		if i < 0 {
+			p.fail(fmt.Errorf("invalid flags %q", s))
+			return 0
+		}
+		flags |= 1 << uint(len(tcpFlags)-1-i)
+	}
+	return flags
+}
@@ -0,0 +1,64 @@
+//go:generate ffjson $GOFILE
+
+package entry
+
+import "strconv"
+
+// NfdumpEntryV1 is the version 1 of the schema, where every value other than
+// the geographic data is encoded as a string.
+type NfdumpEntryV1 struct {
+	Host          string      `json:"host"`
+	InBytes       string      `json:"in_bytes"`
+	InPkts        string      `json:"in_pkts"`
+	Ipv4SrcAddr   string      `json:"ipv4_src_addr,omitempty"`
+	Ipv4DstAddr   string      `json:"ipv4_dst_addr,omitempty"`
+	Ipv6SrcAddr   string      `json:"ipv6_src_addr,omitempty"`
+	Ipv6DstAddr   string      `json:"ipv6_dst_addr,omitempty"`
+	Protocol      string      `json:"protocol"`
+	L4SrcPort     string      `json:"l4_src_port"`
+	L4DstPort     string      `json:"l4_dst_port"`
+	SrcAs         string      `json:"src_as"`
+	DstAs         string      `json:"dst_as"`
+	SrcAsOrg      string      `json:"src_asn_org,omitempty"`
+	DstAsOrg      string      `json:"dst_asn_org,omitempty"`
+	InputSnmp     string      `json:"input_snmp"`
+	OutputSnmp    string      `json:"output_snmp"`
+	TcpFlags      string      `json:"tcp_flags"`
+	SrcTos        string      `json:"src_tos"`
+	FirstSwitched string      `json:"first_switched"`
+	LastSwitched  string      `json:"last_switched"`
+	SamplingRate  string      `json:"sampling_rate,omitempty"`
+	GeoIPSrc      *GeoIPEntry `json:"geoip_src,omitempty"`
+	GeoIPDst      *GeoIPEntry `json:"geoip_dst,omitempty"`
+}
+
+func newNfdumpEntryV1(e *NfdumpEntry) *NfdumpEntryV1 {
+	v1 := &NfdumpEntryV1{
+		Host:          e.Host,
+		InBytes:       strconv.FormatUint(e.InBytes, 10),
+		InPkts:        strconv.FormatUint(e.InPkts, 10),
+		Ipv4SrcAddr:   e.Ipv4SrcAddr,
+		Ipv4DstAddr:   e.Ipv4DstAddr,
+		Ipv6SrcAddr:   e.Ipv6SrcAddr,
+		Ipv6DstAddr:   e.Ipv6DstAddr,
+		Protocol:      strconv.FormatUint(uint64(e.Protocol), 10),
+		L4SrcPort:     strconv.FormatUint(uint64(e.L4SrcPort), 10),
+		L4DstPort:     strconv.FormatUint(uint64(e.L4DstPort), 10),
+		SrcAs:         strconv.FormatUint(uint64(e.SrcAs), 10),
+		DstAs:         strconv.FormatUint(uint64(e.DstAs), 10),
+		SrcAsOrg:      e.SrcAsOrg,
+		DstAsOrg:      e.DstAsOrg,
+		InputSnmp:     strconv.FormatUint(uint64(e.InputSnmp), 10),
+		OutputSnmp:    strconv.FormatUint(uint64(e.OutputSnmp), 10),
+		TcpFlags:      strconv.FormatUint(uint64(e.TcpFlags), 10),
+		SrcTos:        strconv.FormatUint(uint64(e.SrcTos), 10),
+		FirstSwitched: e.FirstSwitched,
+		LastSwitched:  e.LastSwitched,
+		GeoIPSrc:      e.GeoIPSrc,
+		GeoIPDst:      e.GeoIPDst,
+	}
+	if e.SamplingRate != 0 {
+		v1.SamplingRate = strconv.FormatUint(uint64(e.SamplingRate), 10)
+	}
+	return v1
+}
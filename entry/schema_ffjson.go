@@ -0,0 +1,1292 @@
+// DO NOT EDIT!
+// Code generated by ffjson <https://github.com/pquerna/ffjson>
+// source: schema.go
+// DO NOT EDIT!
+
+package entry
+
+import (
+	"bytes"
+	"fmt"
+	fflib "github.com/pquerna/ffjson/fflib/v1"
+)
+
+func (mj *NfdumpEntryV1) MarshalJSON() ([]byte, error) {
+	var buf fflib.Buffer
+	if mj == nil {
+		buf.WriteString("null")
+		return buf.Bytes(), nil
+	}
+	err := mj.MarshalJSONBuf(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+func (mj *NfdumpEntryV1) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
+	if mj == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	var err error
+	var obj []byte
+	_ = obj
+	_ = err
+	buf.WriteString(`{ "host":`)
+	fflib.WriteJsonString(buf, string(mj.Host))
+	buf.WriteString(`,"in_bytes":`)
+	fflib.WriteJsonString(buf, string(mj.InBytes))
+	buf.WriteString(`,"in_pkts":`)
+	fflib.WriteJsonString(buf, string(mj.InPkts))
+	buf.WriteByte(',')
+	if len(mj.Ipv4SrcAddr) != 0 {
+		buf.WriteString(`"ipv4_src_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv4SrcAddr))
+		buf.WriteByte(',')
+	}
+	if len(mj.Ipv4DstAddr) != 0 {
+		buf.WriteString(`"ipv4_dst_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv4DstAddr))
+		buf.WriteByte(',')
+	}
+	if len(mj.Ipv6SrcAddr) != 0 {
+		buf.WriteString(`"ipv6_src_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv6SrcAddr))
+		buf.WriteByte(',')
+	}
+	if len(mj.Ipv6DstAddr) != 0 {
+		buf.WriteString(`"ipv6_dst_addr":`)
+		fflib.WriteJsonString(buf, string(mj.Ipv6DstAddr))
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"protocol":`)
+	fflib.WriteJsonString(buf, string(mj.Protocol))
+	buf.WriteString(`,"l4_src_port":`)
+	fflib.WriteJsonString(buf, string(mj.L4SrcPort))
+	buf.WriteString(`,"l4_dst_port":`)
+	fflib.WriteJsonString(buf, string(mj.L4DstPort))
+	buf.WriteString(`,"src_as":`)
+	fflib.WriteJsonString(buf, string(mj.SrcAs))
+	buf.WriteString(`,"dst_as":`)
+	fflib.WriteJsonString(buf, string(mj.DstAs))
+	buf.WriteByte(',')
+	if len(mj.SrcAsOrg) != 0 {
+		buf.WriteString(`"src_asn_org":`)
+		fflib.WriteJsonString(buf, string(mj.SrcAsOrg))
+		buf.WriteByte(',')
+	}
+	if len(mj.DstAsOrg) != 0 {
+		buf.WriteString(`"dst_asn_org":`)
+		fflib.WriteJsonString(buf, string(mj.DstAsOrg))
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"input_snmp":`)
+	fflib.WriteJsonString(buf, string(mj.InputSnmp))
+	buf.WriteString(`,"output_snmp":`)
+	fflib.WriteJsonString(buf, string(mj.OutputSnmp))
+	buf.WriteString(`,"tcp_flags":`)
+	fflib.WriteJsonString(buf, string(mj.TcpFlags))
+	buf.WriteString(`,"src_tos":`)
+	fflib.WriteJsonString(buf, string(mj.SrcTos))
+	buf.WriteString(`,"first_switched":`)
+	fflib.WriteJsonString(buf, string(mj.FirstSwitched))
+	buf.WriteString(`,"last_switched":`)
+	fflib.WriteJsonString(buf, string(mj.LastSwitched))
+	buf.WriteByte(',')
+	if len(mj.SamplingRate) != 0 {
+		buf.WriteString(`"sampling_rate":`)
+		fflib.WriteJsonString(buf, string(mj.SamplingRate))
+		buf.WriteByte(',')
+	}
+	if mj.GeoIPSrc != nil {
+		if true {
+			buf.WriteString(`"geoip_src":`)
+
+			{
+
+				err = mj.GeoIPSrc.MarshalJSONBuf(buf)
+				if err != nil {
+					return err
+				}
+
+			}
+			buf.WriteByte(',')
+		}
+	}
+	if mj.GeoIPDst != nil {
+		if true {
+			buf.WriteString(`"geoip_dst":`)
+
+			{
+
+				err = mj.GeoIPDst.MarshalJSONBuf(buf)
+				if err != nil {
+					return err
+				}
+
+			}
+			buf.WriteByte(',')
+		}
+	}
+	buf.Rewind(1)
+	buf.WriteByte('}')
+	return nil
+}
+
+const (
+	ffj_t_NfdumpEntryV1base = iota
+	ffj_t_NfdumpEntryV1no_such_key
+
+	ffj_t_NfdumpEntryV1_Host
+
+	ffj_t_NfdumpEntryV1_InBytes
+
+	ffj_t_NfdumpEntryV1_InPkts
+
+	ffj_t_NfdumpEntryV1_Ipv4SrcAddr
+
+	ffj_t_NfdumpEntryV1_Ipv4DstAddr
+
+	ffj_t_NfdumpEntryV1_Ipv6SrcAddr
+
+	ffj_t_NfdumpEntryV1_Ipv6DstAddr
+
+	ffj_t_NfdumpEntryV1_Protocol
+
+	ffj_t_NfdumpEntryV1_L4SrcPort
+
+	ffj_t_NfdumpEntryV1_L4DstPort
+
+	ffj_t_NfdumpEntryV1_SrcAs
+
+	ffj_t_NfdumpEntryV1_DstAs
+
+	ffj_t_NfdumpEntryV1_SrcAsOrg
+
+	ffj_t_NfdumpEntryV1_DstAsOrg
+
+	ffj_t_NfdumpEntryV1_InputSnmp
+
+	ffj_t_NfdumpEntryV1_OutputSnmp
+
+	ffj_t_NfdumpEntryV1_TcpFlags
+
+	ffj_t_NfdumpEntryV1_SrcTos
+
+	ffj_t_NfdumpEntryV1_FirstSwitched
+
+	ffj_t_NfdumpEntryV1_LastSwitched
+
+	ffj_t_NfdumpEntryV1_SamplingRate
+
+	ffj_t_NfdumpEntryV1_GeoIPSrc
+
+	ffj_t_NfdumpEntryV1_GeoIPDst
+)
+
+var ffj_key_NfdumpEntryV1_Host = []byte("host")
+
+var ffj_key_NfdumpEntryV1_InBytes = []byte("in_bytes")
+
+var ffj_key_NfdumpEntryV1_InPkts = []byte("in_pkts")
+
+var ffj_key_NfdumpEntryV1_Ipv4SrcAddr = []byte("ipv4_src_addr")
+
+var ffj_key_NfdumpEntryV1_Ipv4DstAddr = []byte("ipv4_dst_addr")
+
+var ffj_key_NfdumpEntryV1_Ipv6SrcAddr = []byte("ipv6_src_addr")
+
+var ffj_key_NfdumpEntryV1_Ipv6DstAddr = []byte("ipv6_dst_addr")
+
+var ffj_key_NfdumpEntryV1_Protocol = []byte("protocol")
+
+var ffj_key_NfdumpEntryV1_L4SrcPort = []byte("l4_src_port")
+
+var ffj_key_NfdumpEntryV1_L4DstPort = []byte("l4_dst_port")
+
+var ffj_key_NfdumpEntryV1_SrcAs = []byte("src_as")
+
+var ffj_key_NfdumpEntryV1_DstAs = []byte("dst_as")
+
+var ffj_key_NfdumpEntryV1_SrcAsOrg = []byte("src_asn_org")
+
+var ffj_key_NfdumpEntryV1_DstAsOrg = []byte("dst_asn_org")
+
+var ffj_key_NfdumpEntryV1_InputSnmp = []byte("input_snmp")
+
+var ffj_key_NfdumpEntryV1_OutputSnmp = []byte("output_snmp")
+
+var ffj_key_NfdumpEntryV1_TcpFlags = []byte("tcp_flags")
+
+var ffj_key_NfdumpEntryV1_SrcTos = []byte("src_tos")
+
+var ffj_key_NfdumpEntryV1_FirstSwitched = []byte("first_switched")
+
+var ffj_key_NfdumpEntryV1_LastSwitched = []byte("last_switched")
+
+var ffj_key_NfdumpEntryV1_SamplingRate = []byte("sampling_rate")
+
+var ffj_key_NfdumpEntryV1_GeoIPSrc = []byte("geoip_src")
+
+var ffj_key_NfdumpEntryV1_GeoIPDst = []byte("geoip_dst")
+
+func (uj *NfdumpEntryV1) UnmarshalJSON(input []byte) error {
+	fs := fflib.NewFFLexer(input)
+	return uj.UnmarshalJSONFFLexer(fs, fflib.FFParse_map_start)
+}
+
+func (uj *NfdumpEntryV1) UnmarshalJSONFFLexer(fs *fflib.FFLexer, state fflib.FFParseState) error {
+	var err error = nil
+	currentKey := ffj_t_NfdumpEntryV1base
+	_ = currentKey
+	tok := fflib.FFTok_init
+	wantedTok := fflib.FFTok_init
+
+mainparse:
+	for {
+		tok = fs.Scan()
+		//	println(fmt.Sprintf("debug: tok: %v  state: %v", tok, state))
+		if tok == fflib.FFTok_error {
+			goto tokerror
+		}
+
+		switch state {
+
+		case fflib.FFParse_map_start:
+			if tok != fflib.FFTok_left_bracket {
+				wantedTok = fflib.FFTok_left_bracket
+				goto wrongtokenerror
+			}
+			state = fflib.FFParse_want_key
+			continue
+
+		case fflib.FFParse_after_value:
+			if tok == fflib.FFTok_comma {
+				state = fflib.FFParse_want_key
+			} else if tok == fflib.FFTok_right_bracket {
+				goto done
+			} else {
+				wantedTok = fflib.FFTok_comma
+				goto wrongtokenerror
+			}
+
+		case fflib.FFParse_want_key:
+			// json {} ended. goto exit. woo.
+			if tok == fflib.FFTok_right_bracket {
+				goto done
+			}
+			if tok != fflib.FFTok_string {
+				wantedTok = fflib.FFTok_string
+				goto wrongtokenerror
+			}
+
+			kn := fs.Output.Bytes()
+			if len(kn) <= 0 {
+				// "" case. hrm.
+				currentKey = ffj_t_NfdumpEntryV1no_such_key
+				state = fflib.FFParse_want_colon
+				goto mainparse
+			} else {
+				switch kn[0] {
+
+				case 'd':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_DstAs, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_DstAs
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_DstAsOrg, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_DstAsOrg
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'f':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_FirstSwitched, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_FirstSwitched
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'g':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_GeoIPSrc, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_GeoIPSrc
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_GeoIPDst, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_GeoIPDst
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'h':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_Host, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_Host
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'i':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_InBytes, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_InBytes
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_InPkts, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_InPkts
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_Ipv4SrcAddr, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_Ipv4SrcAddr
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_Ipv4DstAddr, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_Ipv4DstAddr
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_Ipv6SrcAddr, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_Ipv6SrcAddr
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_Ipv6DstAddr, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_Ipv6DstAddr
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_InputSnmp, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_InputSnmp
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'l':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_L4SrcPort, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_L4SrcPort
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_L4DstPort, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_L4DstPort
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_LastSwitched, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_LastSwitched
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'o':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_OutputSnmp, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_OutputSnmp
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 'p':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_Protocol, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_Protocol
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 's':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_SrcAs, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_SrcAs
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_SrcAsOrg, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_SrcAsOrg
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_SrcTos, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_SrcTos
+						state = fflib.FFParse_want_colon
+						goto mainparse
+
+					} else if bytes.Equal(ffj_key_NfdumpEntryV1_SamplingRate, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_SamplingRate
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				case 't':
+
+					if bytes.Equal(ffj_key_NfdumpEntryV1_TcpFlags, kn) {
+						currentKey = ffj_t_NfdumpEntryV1_TcpFlags
+						state = fflib.FFParse_want_colon
+						goto mainparse
+					}
+
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_GeoIPDst, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_GeoIPDst
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_GeoIPSrc, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_GeoIPSrc
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_SamplingRate, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_SamplingRate
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_LastSwitched, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_LastSwitched
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_FirstSwitched, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_FirstSwitched
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_SrcTos, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_SrcTos
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_TcpFlags, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_TcpFlags
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_OutputSnmp, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_OutputSnmp
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_InputSnmp, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_InputSnmp
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_DstAsOrg, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_DstAsOrg
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_SrcAsOrg, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_SrcAsOrg
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_DstAs, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_DstAs
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_SrcAs, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_SrcAs
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_L4DstPort, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_L4DstPort
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_L4SrcPort, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_L4SrcPort
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.SimpleLetterEqualFold(ffj_key_NfdumpEntryV1_Protocol, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_Protocol
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_Ipv6DstAddr, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_Ipv6DstAddr
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_Ipv6SrcAddr, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_Ipv6SrcAddr
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_Ipv4DstAddr, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_Ipv4DstAddr
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_Ipv4SrcAddr, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_Ipv4SrcAddr
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_InPkts, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_InPkts
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_InBytes, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_InBytes
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				if fflib.EqualFoldRight(ffj_key_NfdumpEntryV1_Host, kn) {
+					currentKey = ffj_t_NfdumpEntryV1_Host
+					state = fflib.FFParse_want_colon
+					goto mainparse
+				}
+
+				currentKey = ffj_t_NfdumpEntryV1no_such_key
+				state = fflib.FFParse_want_colon
+				goto mainparse
+			}
+
+		case fflib.FFParse_want_colon:
+			if tok != fflib.FFTok_colon {
+				wantedTok = fflib.FFTok_colon
+				goto wrongtokenerror
+			}
+			state = fflib.FFParse_want_value
+			continue
+		case fflib.FFParse_want_value:
+
+			if tok == fflib.FFTok_left_brace || tok == fflib.FFTok_left_bracket || tok == fflib.FFTok_integer || tok == fflib.FFTok_double || tok == fflib.FFTok_string || tok == fflib.FFTok_bool || tok == fflib.FFTok_null {
+				switch currentKey {
+
+				case ffj_t_NfdumpEntryV1_Host:
+					goto handle_Host
+
+				case ffj_t_NfdumpEntryV1_InBytes:
+					goto handle_InBytes
+
+				case ffj_t_NfdumpEntryV1_InPkts:
+					goto handle_InPkts
+
+				case ffj_t_NfdumpEntryV1_Ipv4SrcAddr:
+					goto handle_Ipv4SrcAddr
+
+				case ffj_t_NfdumpEntryV1_Ipv4DstAddr:
+					goto handle_Ipv4DstAddr
+
+				case ffj_t_NfdumpEntryV1_Ipv6SrcAddr:
+					goto handle_Ipv6SrcAddr
+
+				case ffj_t_NfdumpEntryV1_Ipv6DstAddr:
+					goto handle_Ipv6DstAddr
+
+				case ffj_t_NfdumpEntryV1_Protocol:
+					goto handle_Protocol
+
+				case ffj_t_NfdumpEntryV1_L4SrcPort:
+					goto handle_L4SrcPort
+
+				case ffj_t_NfdumpEntryV1_L4DstPort:
+					goto handle_L4DstPort
+
+				case ffj_t_NfdumpEntryV1_SrcAs:
+					goto handle_SrcAs
+
+				case ffj_t_NfdumpEntryV1_DstAs:
+					goto handle_DstAs
+
+				case ffj_t_NfdumpEntryV1_SrcAsOrg:
+					goto handle_SrcAsOrg
+
+				case ffj_t_NfdumpEntryV1_DstAsOrg:
+					goto handle_DstAsOrg
+
+				case ffj_t_NfdumpEntryV1_InputSnmp:
+					goto handle_InputSnmp
+
+				case ffj_t_NfdumpEntryV1_OutputSnmp:
+					goto handle_OutputSnmp
+
+				case ffj_t_NfdumpEntryV1_TcpFlags:
+					goto handle_TcpFlags
+
+				case ffj_t_NfdumpEntryV1_SrcTos:
+					goto handle_SrcTos
+
+				case ffj_t_NfdumpEntryV1_FirstSwitched:
+					goto handle_FirstSwitched
+
+				case ffj_t_NfdumpEntryV1_LastSwitched:
+					goto handle_LastSwitched
+
+				case ffj_t_NfdumpEntryV1_SamplingRate:
+					goto handle_SamplingRate
+
+				case ffj_t_NfdumpEntryV1_GeoIPSrc:
+					goto handle_GeoIPSrc
+
+				case ffj_t_NfdumpEntryV1_GeoIPDst:
+					goto handle_GeoIPDst
+
+				case ffj_t_NfdumpEntryV1no_such_key:
+					err = fs.SkipField(tok)
+					if err != nil {
+						return fs.WrapErr(err)
+					}
+					state = fflib.FFParse_after_value
+					goto mainparse
+				}
+			} else {
+				goto wantedvalue
+			}
+		}
+	}
+
+handle_Host:
+
+	/* handler: uj.Host type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.Host = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_InBytes:
+
+	/* handler: uj.InBytes type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.InBytes = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_InPkts:
+
+	/* handler: uj.InPkts type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.InPkts = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_Ipv4SrcAddr:
+
+	/* handler: uj.Ipv4SrcAddr type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.Ipv4SrcAddr = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_Ipv4DstAddr:
+
+	/* handler: uj.Ipv4DstAddr type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.Ipv4DstAddr = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_Ipv6SrcAddr:
+
+	/* handler: uj.Ipv6SrcAddr type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.Ipv6SrcAddr = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_Ipv6DstAddr:
+
+	/* handler: uj.Ipv6DstAddr type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.Ipv6DstAddr = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_Protocol:
+
+	/* handler: uj.Protocol type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.Protocol = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_L4SrcPort:
+
+	/* handler: uj.L4SrcPort type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.L4SrcPort = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_L4DstPort:
+
+	/* handler: uj.L4DstPort type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.L4DstPort = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SrcAs:
+
+	/* handler: uj.SrcAs type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.SrcAs = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_DstAs:
+
+	/* handler: uj.DstAs type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.DstAs = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SrcAsOrg:
+
+	/* handler: uj.SrcAsOrg type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.SrcAsOrg = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_DstAsOrg:
+
+	/* handler: uj.DstAsOrg type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.DstAsOrg = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_InputSnmp:
+
+	/* handler: uj.InputSnmp type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.InputSnmp = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_OutputSnmp:
+
+	/* handler: uj.OutputSnmp type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.OutputSnmp = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_TcpFlags:
+
+	/* handler: uj.TcpFlags type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.TcpFlags = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SrcTos:
+
+	/* handler: uj.SrcTos type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.SrcTos = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_FirstSwitched:
+
+	/* handler: uj.FirstSwitched type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.FirstSwitched = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_LastSwitched:
+
+	/* handler: uj.LastSwitched type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.LastSwitched = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_SamplingRate:
+
+	/* handler: uj.SamplingRate type=string kind=string quoted=false*/
+
+	{
+
+		{
+			if tok != fflib.FFTok_string && tok != fflib.FFTok_null {
+				return fs.WrapErr(fmt.Errorf("cannot unmarshal %s into Go value for string", tok))
+			}
+		}
+
+		if tok == fflib.FFTok_null {
+
+		} else {
+
+			outBuf := fs.Output.Bytes()
+
+			uj.SamplingRate = string(string(outBuf))
+
+		}
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_GeoIPSrc:
+
+	/* handler: uj.GeoIPSrc type=entry.GeoIPEntry kind=struct quoted=false*/
+
+	{
+		if tok == fflib.FFTok_null {
+
+			uj.GeoIPSrc = nil
+
+			state = fflib.FFParse_after_value
+			goto mainparse
+		}
+
+		if uj.GeoIPSrc == nil {
+			uj.GeoIPSrc = new(GeoIPEntry)
+		}
+
+		err = uj.GeoIPSrc.UnmarshalJSONFFLexer(fs, fflib.FFParse_want_key)
+		if err != nil {
+			return err
+		}
+		state = fflib.FFParse_after_value
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+handle_GeoIPDst:
+
+	/* handler: uj.GeoIPDst type=entry.GeoIPEntry kind=struct quoted=false*/
+
+	{
+		if tok == fflib.FFTok_null {
+
+			uj.GeoIPDst = nil
+
+			state = fflib.FFParse_after_value
+			goto mainparse
+		}
+
+		if uj.GeoIPDst == nil {
+			uj.GeoIPDst = new(GeoIPEntry)
+		}
+
+		err = uj.GeoIPDst.UnmarshalJSONFFLexer(fs, fflib.FFParse_want_key)
+		if err != nil {
+			return err
+		}
+		state = fflib.FFParse_after_value
+	}
+
+	state = fflib.FFParse_after_value
+	goto mainparse
+
+wantedvalue:
+	return fs.WrapErr(fmt.Errorf("wanted value token, but got token: %v", tok))
+wrongtokenerror:
+	return fs.WrapErr(fmt.Errorf("ffjson: wanted token: %v, but got token: %v output=%s", wantedTok, tok, fs.Output.String()))
+tokerror:
+	if fs.BigError != nil {
+		return fs.WrapErr(fs.BigError)
+	}
+	err = fs.Error.ToError()
+	if err != nil {
+		return fs.WrapErr(err)
+	}
+	panic("ffjson-generated: unreachable, please report bug.")
+done:
+	return nil
+}
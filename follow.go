@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var (
+	follow       = flag.Bool("follow", false, "Keep reading the file as it grows, like tail -F, or keep polling the directory for new nfcapd files")
+	pollInterval = flag.Duration("pollInterval", time.Second, "How often to check for new data with -follow")
+	nfdumpPath   = flag.String("nfdump", "nfdump", "nfdump command used to read the nfcapd files in layouts not supported natively")
+)
+
+var (
+	errTruncated = errors.New("file truncated")
+	errRotated   = errors.New("file replaced")
+)
+
+// nfcapdFile matches the names of the files completed by nfcapd, which writes
+// to nfcapd.current.<pid> and renames the file at the end of each interval.
+var nfcapdFile = regexp.MustCompile(`^nfcapd\.[0-9]{12}$`)
+
+// follower reads a file like tail -F. At the end of the file, it waits for
+// more data until stop is closed. If the file is truncated, it reads it again
+// from the beginning, and if the path is given to a new file, it switches to
+// it. Read reports these events with errTruncated and errRotated, so the line
+// positions can be reset.
+type follower struct {
+	done   <-chan struct{}
+	stop   <-chan struct{}
+	path   string
+	file   *os.File
+	offset int64
+}
+
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		if err := f.reopen(); err != nil {
+			return 0, err
+		}
+		select {
+		case <-f.done:
+			return 0, io.EOF
+		case <-f.stop:
+			return 0, io.EOF
+		case <-time.After(*pollInterval):
+		}
+	}
+}
+
+// reopen checks, once the end of the file has been reached, whether it was
+// truncated or replaced.
+func (f *follower) reopen() error {
+	current, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		// The file may be in the middle of a rotation.
+		return nil
+	}
+	if !os.SameFile(current, info) {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return nil
+		}
+		f.file.Close()
+		f.file, f.offset = file, 0
+		return errRotated
+	}
+	if current.Size() < f.offset {
+		if _, err := f.file.Seek(0, os.SEEK_SET); err != nil {
+			return err
+		}
+		f.offset = 0
+		return errTruncated
+	}
+	return nil
+}
+
+func (f *follower) Close() error {
+	return f.file.Close()
+}
+
+// parseDir parses the nfcapd files found in the directory of in, oldest
+// first, and sends their flows to lines. With -follow, it keeps polling the
+// directory for new files until stop or done are closed. Files that can not
+// be read are logged, and tried again on the next poll. Once done, parseDir
+// returns an error if some of them could never be read.
+func parseDir(done, stop <-chan struct{}, in *input, lines chan<- record) error {
+	dir := in.path
+	seen := make(map[string]bool)
+	failed := make(map[string]bool)
+	result := func() error {
+		if len(failed) > 0 {
+			return fmt.Errorf("%d nfcapd files failed", len(failed))
+		}
+		return nil
+	}
+	for {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			name := info.Name()
+			if seen[name] || !info.Mode().IsRegular() || !nfcapdFile.MatchString(name) {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if checkpoints.done(checkpointKey(path)) {
+				if *verbose {
+					logger.Printf("Skipping %s, already processed.", path)
+				}
+				seen[name] = true
+				continue
+			}
+			logger.Printf("Reading %s.", path)
+			if err := parse(done, stop, in, path, lines); err != nil {
+				logger.Printf("%s could not be read: %s", path, err)
+				failed[name] = true
+			} else {
+				seen[name] = true
+				delete(failed, name)
+			}
+			select {
+			case <-done:
+				return result()
+			case <-stop:
+				return result()
+			default:
+			}
+		}
+		if !*follow {
+			return result()
+		}
+		select {
+		case <-done:
+			return result()
+		case <-stop:
+			return result()
+		case <-time.After(*pollInterval):
+		}
+	}
+}
+
+// command is the output of a running command. Close waits for the command
+// to exit and returns its status.
+type command struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *command) Close() error {
+	c.ReadCloser.Close()
+	return c.cmd.Wait()
+}
+
+// nfdump runs nfdump to convert the nfcapd file found at path to the pipe
+// format, for the layouts that the nfcapd package does not support.
+func nfdump(path string) (io.ReadCloser, error) {
+	cmd := exec.Command(*nfdumpPath, "-q", "-o", "pipe", "-r", path)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &command{out, cmd}, nil
+}
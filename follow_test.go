@@ -0,0 +1,234 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFollower(t *testing.T) {
+	dir, err := ioutil.TempDir("", "follow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flows.txt")
+	defer func(d time.Duration) { *pollInterval = d }(*pollInterval)
+	*pollInterval = time.Millisecond
+
+	write := func(flag int, data string) func() error {
+		return func() error {
+			file, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0644)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = file.WriteString(data)
+			return err
+		}
+	}
+	stop := make(chan struct{})
+	var tests = []struct {
+		name   string
+		action func() error
+		data   string
+		err    error
+	}{
+		{"read", nil, "a\nb\n", nil},
+		{"append", write(os.O_APPEND, "c\n"), "c\n", nil},
+		{"truncate", write(os.O_TRUNC, "d\n"), "", errTruncated},
+		{"read truncated", nil, "d\n", nil},
+		{"rotate", func() error {
+			if err := os.Rename(path, path+".1"); err != nil {
+				return err
+			}
+			return write(os.O_EXCL, "e\n")()
+		}, "", errRotated},
+		{"read rotated", nil, "e\n", nil},
+		{"stop", func() error { close(stop); return nil }, "", io.EOF},
+	}
+
+	if err := write(0, "a\nb\n")(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &follower{done: make(chan struct{}), stop: stop, path: path, file: file}
+	defer f.Close()
+	buf := make([]byte, 64)
+	for _, tt := range tests {
+		if tt.action != nil {
+			if err := tt.action(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n, err := f.Read(buf)
+		if string(buf[:n]) != tt.data || err != tt.err {
+			t.Errorf("%s: expected %q (%v), actual %q (%v)", tt.name, tt.data, tt.err, buf[:n], err)
+		}
+	}
+}
+
+// TestFollowResume checks that a followed file is read again with -resume
+// after the follower is stopped, from the last line delivered.
+func TestFollowResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flows.txt")
+	checkpointPath := filepath.Join(dir, "checkpoint.json")
+	defer func(c *checkpointer) { checkpoints = c }(checkpoints)
+	defer func(f, r bool) { *follow, *resume = f, r }(*follow, *resume)
+	defer func(d time.Duration) { *pollInterval = d }(*pollInterval)
+	*pollInterval = time.Millisecond
+	*resume = true
+
+	var tests = []struct {
+		name   string
+		follow bool
+		data   string
+		lines  []string
+		expec  checkpoint
+	}{
+		{"follow", true, "a\nb\n", []string{"a", "b"}, checkpoint{Offset: 4, Line: 2}},
+		{"follow again", true, "", nil, checkpoint{Offset: 4, Line: 2}},
+		{"resume", false, "c\n", []string{"c"}, checkpoint{Offset: 6, Line: 3, Done: true}},
+		{"resume done", false, "", nil, checkpoint{Offset: 6, Line: 3, Done: true}},
+		{"follow done", true, "d\n", []string{"d"}, checkpoint{Offset: 8, Line: 4}},
+	}
+	for _, tt := range tests {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = file.WriteString(tt.data)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkpoints, err = openCheckpointer(checkpointPath); err != nil {
+			t.Fatal(err)
+		}
+		*follow = tt.follow
+
+		done := make(chan struct{})
+		stop := make(chan struct{})
+		lines, errc := parser(done, stop, []*input{{path: path, info: info}})
+		var actual []string
+		for range tt.lines {
+			rec, ok := <-lines
+			if !ok {
+				break
+			}
+			actual = append(actual, rec.line)
+			rec.ack(true)
+		}
+		close(stop)
+		for rec := range lines {
+			actual = append(actual, rec.line)
+			rec.ack(true)
+		}
+		close(done)
+		if err := <-errc; err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+		}
+		if !reflect.DeepEqual(actual, tt.lines) {
+			t.Errorf("%s: expected lines %q, actual %q", tt.name, tt.lines, actual)
+		}
+		if err := checkpoints.save(); err != nil {
+			t.Fatal(err)
+		}
+		if c := checkpoints.all[checkpointKey(path)]; c != tt.expec {
+			t.Errorf("%s: expected checkpoint %+v, actual %+v", tt.name, tt.expec, c)
+		}
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfcapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	blob, err := ioutil.ReadFile("nfcapd/testdata/flows.nfcapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second file ends in the middle of the header of its first block,
+	// past the file header and the stat record.
+	good := filepath.Join(dir, "nfcapd.201605161900")
+	bad := filepath.Join(dir, "nfcapd.201605161905")
+	if err := ioutil.WriteFile(good, blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f bool) { *follow = f }(*follow)
+	defer func(d time.Duration) { *pollInterval = d }(*pollInterval)
+	*pollInterval = time.Millisecond
+
+	var tests = []struct {
+		name   string
+		follow bool
+		fix    bool // whether the second file is completed while followed
+		flows  int
+		err    bool
+	}{
+		{"failed file", false, false, 3, true},
+		{"failed file retried", true, true, 6, false},
+	}
+	for _, tt := range tests {
+		if err := ioutil.WriteFile(bad, blob[:140+136+4], 0644); err != nil {
+			t.Fatal(err)
+		}
+		*follow = tt.follow
+		done := make(chan struct{})
+		stop := make(chan struct{})
+		in := &input{path: dir, info: info}
+		lines, errc := parser(done, stop, []*input{in})
+		flows := 0
+		for ; flows < 3; flows++ {
+			<-lines
+		}
+		if tt.fix {
+			// Give the reader time to fail on the truncated file.
+			time.Sleep(50 * time.Millisecond)
+			if err := ioutil.WriteFile(bad+".tmp", blob, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Rename(bad+".tmp", bad); err != nil {
+				t.Fatal(err)
+			}
+			for ; flows < tt.flows; flows++ {
+				<-lines
+			}
+		}
+		if tt.follow {
+			close(stop)
+		}
+		for range lines {
+			flows++
+		}
+		close(done)
+		if err := <-errc; (err != nil) != tt.err || (in.err != nil) != tt.err {
+			t.Errorf("%s: expected error %v, actual %v", tt.name, tt.err, err)
+		}
+		if flows != tt.flows {
+			t.Errorf("%s: expected %d flows, actual %d", tt.name, tt.flows, flows)
+		}
+	}
+}
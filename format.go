@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+var inputFormat = flag.String("format", "pipe", "Format of the nfdump lines read, as given to nfdump -o: pipe, csv or fmt:format")
+
+// formatLayout is the layout of the lines given with -format, or nil for the
+// pipe format. In the csv format it is the default one, used until a header
+// is found.
+var formatLayout *entry.Layout
+
+// checkFormat validates -format and sets formatLayout.
+func checkFormat() error {
+	var err error
+	switch {
+	case *inputFormat == "pipe":
+	case *inputFormat == "csv":
+		formatLayout, err = entry.NewCSVLayout(entry.CSVHeader)
+	case strings.HasPrefix(*inputFormat, "fmt:"):
+		formatLayout, err = entry.NewFmtLayout(strings.TrimPrefix(*inputFormat, "fmt:"))
+	default:
+		err = fmt.Errorf("unknown format %q", *inputFormat)
+	}
+	return err
+}
+
+// csvSummary starts the summary printed by nfdump after the csv lines. It is
+// followed by a header of its own, starting with csvSummaryColumn, and the
+// totals.
+const (
+	csvSummary       = "Summary"
+	csvSummaryColumn = "flows,"
+)
+
+// lineFormat keeps track of the layout of the lines of an input. In the csv
+// format, the lines follow a header, which may be repeated, and are followed
+// by a summary. A nil *lineFormat reads the pipe format.
+type lineFormat struct {
+	csv     bool
+	layout  *entry.Layout
+	headed  bool // a header has been read
+	summary bool // the lines read belong to the summary
+}
+
+// newLineFormat returns the lineFormat of an input in the format given with
+// -format.
+func newLineFormat() *lineFormat {
+	if formatLayout == nil {
+		return nil
+	}
+	return &lineFormat{csv: *inputFormat == "csv", layout: formatLayout}
+}
+
+// current returns the layout of the next line, or nil for the pipe format.
+func (lf *lineFormat) current() *entry.Layout {
+	if lf == nil {
+		return nil
+	}
+	return lf.layout
+}
+
+// skip reports whether line holds no flow, i.e. it is a blank, header or
+// summary line of the csv format. Headers set the layout of the lines that
+// follow them. A line that looks like a header but can not be read is
+// skipped, and its error is returned, leaving the layout as it was.
+func (lf *lineFormat) skip(line string) (bool, error) {
+	if lf == nil || !lf.csv {
+		return false, nil
+	}
+	if line == "" {
+		return true, nil
+	}
+	if r, _ := utf8.DecodeRuneInString(line); !unicode.IsLetter(r) {
+		return lf.summary, nil
+	}
+	switch {
+	case line == csvSummary:
+		lf.summary = true
+		return true, nil
+	case lf.summary && strings.HasPrefix(line, csvSummaryColumn):
+		return true, nil
+	}
+	layout, err := entry.NewCSVLayout(line)
+	if err != nil {
+		return true, fmt.Errorf("invalid csv header: %s", err)
+	}
+	lf.layout, lf.headed, lf.summary = layout, true, false
+	return true, nil
+}
+
+// header reads the first line of r, which holds the input from the
+// beginning, so the layout of the csv lines is known when resuming past their
+// header. It returns the number of bytes read, or the error of the header.
+func (lf *lineFormat) header(r *bufio.Reader) (int64, error) {
+	if lf == nil || !lf.csv {
+		return 0, nil
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if _, err := lf.skip(strings.TrimRight(line, "\r\n")); err != nil {
+		return 0, err
+	}
+	return int64(len(line)), nil
+}
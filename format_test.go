@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+func TestLineFormatSkip(t *testing.T) {
+	layout, err := entry.NewCSVLayout(entry.CSVHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf := &lineFormat{csv: true, layout: layout}
+
+	// The lines are given to the same lineFormat, in order.
+	var tests = []struct {
+		line   string
+		skip   bool
+		err    bool
+		header string
+	}{
+		{"2016-05-16 19:10:44,2016-05-16 19:10:45", false, false, entry.CSVHeader},
+		{"", true, false, entry.CSVHeader},
+		{"ts,sa,da,ibyt", true, false, "ts,sa,da,ibyt"},
+		{"2016-05-16 19:10:44,142.58.103.21,217.12.24.33,692", false, false, "ts,sa,da,ibyt"},
+		{"sa,da,ibyt", true, true, "ts,sa,da,ibyt"},
+		{"2016-05-16 19:10:45,142.58.103.21,217.12.24.33,693", false, false, "ts,sa,da,ibyt"},
+		{"Summary", true, false, "ts,sa,da,ibyt"},
+		{"flows,bytes,packets,avg_bps,avg_pps,avg_bpp", true, false, "ts,sa,da,ibyt"},
+		{"3,2076,3,0,0,692", true, false, "ts,sa,da,ibyt"},
+		{"ts,te,sa,da", true, false, "ts,te,sa,da"},
+		{"2016-05-16 19:10:44,2016-05-16 19:10:45,142.58.103.21,217.12.24.33", false, false, "ts,te,sa,da"},
+		{"flows,bytes", true, true, "ts,te,sa,da"},
+	}
+	for _, tt := range tests {
+		skip, err := lf.skip(tt.line)
+		header := lf.current().Header()
+		if skip != tt.skip || (err != nil) != tt.err || header != tt.header {
+			t.Errorf("skip(%q): expected %v, error %v (%q), actual %v, %v (%q)", tt.line, tt.skip, tt.err, tt.header, skip, err, header)
+		}
+	}
+
+	var pipe *lineFormat
+	for _, line := range []string{"", "ts,sa,da", "Summary"} {
+		if skip, err := pipe.skip(line); skip || err != nil {
+			t.Errorf("skip(%q): expected the pipe format to skip no line, actual %v, %v", line, skip, err)
+		}
+	}
+}
+
+func TestLineFormatHeader(t *testing.T) {
+	var tests = []struct {
+		input  string
+		n      int64
+		header string
+		err    bool
+	}{
+		{"ts,sa,da,ibyt\n2016-05-16 19:10:44,142.58.103.21,217.12.24.33,692\n", 14, "ts,sa,da,ibyt", false},
+		{"ts,sa,da,ibyt\r\n", 15, "ts,sa,da,ibyt", false},
+		{"ts,sa,da,ibyt", 13, "ts,sa,da,ibyt", false},
+		{"2016-05-16 19:10:44,142.58.103.21,217.12.24.33,692\n", 51, entry.CSVHeader, false},
+		{"", 0, entry.CSVHeader, false},
+		{"sa,da,ibyt\n", 0, entry.CSVHeader, true},
+	}
+	layout, err := entry.NewCSVLayout(entry.CSVHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		lf := &lineFormat{csv: true, layout: layout}
+		n, err := lf.header(bufio.NewReader(strings.NewReader(tt.input)))
+		if (err != nil) != tt.err {
+			t.Errorf("header(%q): expected error %v, actual %v", tt.input, tt.err, err)
+			continue
+		}
+		if n != tt.n || lf.current().Header() != tt.header {
+			t.Errorf("header(%q): expected %d (%q), actual %d (%q)", tt.input, tt.n, tt.header, n, lf.current().Header())
+		}
+	}
+
+	var pipe *lineFormat
+	if n, err := pipe.header(bufio.NewReader(strings.NewReader("2|1463425844\n"))); n != 0 || err != nil {
+		t.Errorf("header: expected the pipe format to read nothing, actual %d, %v", n, err)
+	}
+}
+
+func TestCSVHeaders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "format")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flows.csv")
+	defer func(f string, l *entry.Layout) { *inputFormat, formatLayout = f, l }(*inputFormat, formatLayout)
+	*inputFormat = "csv"
+	if err := checkFormat(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name    string
+		input   string
+		lines   []string
+		invalid int64
+		err     bool
+	}{
+		{
+			"header and summary",
+			"ts,sa,da\n2016-05-16 19:10:44,142.58.103.21,217.12.24.33\n\nSummary\nflows,bytes,packets\n1,2,3\n",
+			[]string{"2016-05-16 19:10:44,142.58.103.21,217.12.24.33"},
+			0,
+			false,
+		},
+		{
+			"invalid first header",
+			"sa,da,ibyt\n2016-05-16 19:10:44,142.58.103.21,217.12.24.33\n",
+			nil,
+			0,
+			true,
+		},
+		{
+			"invalid header",
+			"ts,sa,da\n2016-05-16 19:10:44,142.58.103.21,217.12.24.33\nsa,da,ibyt\n2016-05-16 19:10:45,142.58.103.21,217.12.24.33\n",
+			[]string{"2016-05-16 19:10:44,142.58.103.21,217.12.24.33", "2016-05-16 19:10:45,142.58.103.21,217.12.24.33"},
+			1,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		if err := ioutil.WriteFile(path, []byte(tt.input), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		invalid := atomic.LoadInt64(&stats.invalid)
+		done := make(chan struct{})
+		lines, errc := parser(done, nil, []*input{{path: path, info: info}})
+		var actual []string
+		for rec := range lines {
+			actual = append(actual, rec.line)
+		}
+		close(done)
+		if err := <-errc; (err != nil) != tt.err {
+			t.Errorf("%s: expected error %v, actual %v", tt.name, tt.err, err)
+		}
+		if !reflect.DeepEqual(actual, tt.lines) {
+			t.Errorf("%s: expected lines %q, actual %q", tt.name, tt.lines, actual)
+		}
+		if n := atomic.LoadInt64(&stats.invalid) - invalid; n != tt.invalid {
+			t.Errorf("%s: expected %d lines unrecognized, actual %d", tt.name, tt.invalid, n)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/geoip"
+)
+
+var (
+	geoReloadInterval = flag.Duration("geoReloadInterval", 0, "Check the -geoDB and -asnDB files for updates this often and reload them, 0 to only reload them on SIGHUP")
+	geoCacheSize      = flag.Int("geoCacheSize", geoip.DefaultCacheSize, "Number of addresses whose geographic and ASN records are cached, 0 to disable the cache")
+)
+
+// reloadGeo reloads the geographic databases on SIGHUP and, when
+// -geoReloadInterval is set, whenever their files are modified, until stop is
+// closed. The databases in use are kept when the reload fails.
+func reloadGeo(stop <-chan struct{}) {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGHUP)
+	defer signal.Stop(sigc)
+
+	var tick <-chan time.Time
+	if *geoReloadInterval > 0 {
+		t := time.NewTicker(*geoReloadInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-sigc:
+		case <-tick:
+			if !geoip.Modified() {
+				continue
+			}
+		}
+		if err := geoip.Reload(); err != nil {
+			logger.Printf("Geographic database could not be reloaded: %s.", err)
+			continue
+		}
+		logger.Printf("Using geographic database: %s", geoip.Info())
+	}
+}
@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"container/list"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultCacheSize is the number of addresses whose records are cached
+// unless SetCacheSize is used.
+const DefaultCacheSize = 65536
+
+var (
+	// geoCache and asnCache keep the records last looked up by Geo and ASN.
+	// They are purged whenever the databases are replaced.
+	geoCache = newCache(DefaultCacheSize)
+	asnCache = newCache(DefaultCacheSize)
+
+	hits, misses uint64
+)
+
+// SetCacheSize sets the number of addresses whose records are kept by Geo
+// and ASN, each, dropping the least recently used ones first. A size of zero
+// disables the cache.
+func SetCacheSize(size int) {
+	geoCache.resize(size)
+	asnCache.resize(size)
+}
+
+// CacheStats returns the number of lookups answered from the cache and the
+// number of lookups that had to decode the databases.
+func CacheStats() (uint64, uint64) {
+	return atomic.LoadUint64(&hits), atomic.LoadUint64(&misses)
+}
+
+// cacheKey returns the key of ip in the cache, which is the same for an IPv4
+// address and its IPv4-mapped IPv6 form.
+func cacheKey(ip net.IP) string {
+	return string(ip.To16())
+}
+
+// cache is a least recently used cache of records, safe for concurrent use.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheItem struct {
+	key   string
+	value interface{}
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *cache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		atomic.AddUint64(&misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&hits, 1)
+	c.ll.MoveToFront(e)
+	return e.Value.(*cacheItem).value, true
+}
+
+func (c *cache) add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*cacheItem).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheItem{key, value})
+	c.evict()
+}
+
+func (c *cache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	c.evict()
+}
+
+func (c *cache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// evict drops the least recently used records until the cache fits in its
+// size.
+func (c *cache) evict() {
+	for c.ll.Len() > c.size && c.ll.Len() > 0 {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*cacheItem).key)
+	}
+}
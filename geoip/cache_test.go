@@ -0,0 +1,38 @@
+package geoip
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := newCache(2)
+	c.add("a", 1)
+	c.add("b", 2)
+	c.get("a")
+	c.add("c", 3)
+	if _, ok := c.get("b"); ok {
+		t.Error("get(b): expected the least recently used record to be dropped")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("get(%s): expected record", key)
+		}
+	}
+
+	c.resize(1)
+	if len(c.items) != 1 || c.ll.Len() != 1 {
+		t.Errorf("resize: expected 1 record, actual %d", len(c.items))
+	}
+	c.resize(0)
+	c.add("d", 4)
+	if _, ok := c.get("d"); ok {
+		t.Error("get(d): expected disabled cache")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	if cacheKey(net.IPv4(142, 58, 103, 21)) != cacheKey(net.IP{142, 58, 103, 21}) {
+		t.Error("key: expected the same key for both forms of an IPv4 address")
+	}
+}
@@ -5,47 +5,284 @@ package geoip
 import (
 	"fmt"
 	"net"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	maxmind "github.com/oschwald/maxminddb-golang"
 )
 
-var reader *maxmind.Reader
+var (
+	// embedded is the GeoLite2-Country database built into the binary.
+	embedded *database
+
+	// mu guards the databases below, which can be replaced while they are
+	// looked up.
+	mu sync.RWMutex
+
+	// readers are the databases looked up, in order.
+	readers []*database
+
+	// asnReader is the autonomous system database, if any.
+	asnReader *database
+)
+
+// database is an open MaxMind DB file. The path of the embedded database is
+// empty.
+type database struct {
+	*maxmind.Reader
+	path    string
+	modTime time.Time
+}
 
 func init() {
-	var err error
-	reader, err = maxmind.FromBytes(MustAsset("../data/GeoLite2-Country.mmdb"))
+	r, err := maxmind.FromBytes(MustAsset("../data/GeoLite2-Country.mmdb"))
 	if err != nil {
 		panic(err)
 	}
+	embedded = &database{Reader: r}
+	readers = []*database{embedded}
+}
+
+func openDatabase(path string) (*database, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := maxmind.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &database{Reader: r, path: path, modTime: fi.ModTime()}, nil
+}
+
+func openDatabases(paths []string) ([]*database, error) {
+	if len(paths) == 0 {
+		return []*database{embedded}, nil
+	}
+	dbs := make([]*database, 0, len(paths))
+	for _, path := range paths {
+		db, err := openDatabase(path)
+		if err != nil {
+			closeDatabases(dbs...)
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
+
+// closeDatabases closes dbs, except the embedded one which is always kept.
+func closeDatabases(dbs ...*database) {
+	for _, db := range dbs {
+		if db != nil && db != embedded {
+			db.Close()
+		}
+	}
+}
+
+// Open replaces the databases looked up with the MaxMind DB files found at
+// paths, e.g. GeoLite2-City or commercial databases. Addresses are looked up
+// in each database in turn, until one has a record of them. When no path is
+// given, the embedded country database is used.
+func Open(paths ...string) error {
+	dbs, err := openDatabases(paths)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	old := readers
+	readers = dbs
+	geoCache.purge()
+	mu.Unlock()
+	closeDatabases(old...)
+	return nil
+}
+
+// OpenASN opens the MaxMind DB file found at path, e.g. GeoLite2-ASN, to look
+// up the autonomous system of addresses with ASN. An empty path closes the
+// database instead.
+func OpenASN(path string) error {
+	var db *database
+	if path != "" {
+		var err error
+		db, err = openDatabase(path)
+		if err != nil {
+			return err
+		}
+	}
+	mu.Lock()
+	old := asnReader
+	asnReader = db
+	asnCache.purge()
+	mu.Unlock()
+	closeDatabases(old)
+	return nil
+}
+
+// Reload opens again the database files in use, e.g. after they are updated
+// by geoipupdate, and swaps them with the current ones once every file is
+// open. The current databases are kept if any file can not be opened.
+// Lookups done meanwhile use the current databases, and the records cached
+// from them are dropped with them.
+func Reload() error {
+	mu.RLock()
+	var paths []string
+	for _, db := range readers {
+		if db != embedded {
+			paths = append(paths, db.path)
+		}
+	}
+	var asnPath string
+	if asnReader != nil {
+		asnPath = asnReader.path
+	}
+	mu.RUnlock()
+
+	dbs, err := openDatabases(paths)
+	if err != nil {
+		return err
+	}
+	var asn *database
+	if asnPath != "" {
+		asn, err = openDatabase(asnPath)
+		if err != nil {
+			closeDatabases(dbs...)
+			return err
+		}
+	}
+	mu.Lock()
+	old, oldASN := readers, asnReader
+	readers, asnReader = dbs, asn
+	geoCache.purge()
+	asnCache.purge()
+	mu.Unlock()
+	closeDatabases(append(old, oldASN)...)
+	return nil
+}
+
+// Modified reports whether any database file in use was modified since it was
+// opened.
+func Modified() bool {
+	mu.RLock()
+	dbs := append(readers[:len(readers):len(readers)], asnReader)
+	mu.RUnlock()
+	for _, db := range dbs {
+		if db == nil || db == embedded {
+			continue
+		}
+		fi, err := os.Stat(db.path)
+		if err == nil && !fi.ModTime().Equal(db.modTime) {
+			return true
+		}
+	}
+	return false
 }
 
 // Geodata is a struct with the geographic data that we need from the GeoLite2
-// database.
+// databases. The country databases only have the country.
 type Geodata struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
 	Country struct {
 		IsoCode string `maxminddb:"iso_code"`
 	} `maxminddb:"country"`
+	Subdivisions []struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
 	Location struct {
-		Latitude  float64 `maxminddb:"latitude"`
-		Longitude float64 `maxminddb:"longitude"`
+		AccuracyRadius uint16  `maxminddb:"accuracy_radius"`
+		Latitude       float64 `maxminddb:"latitude"`
+		Longitude      float64 `maxminddb:"longitude"`
 	} `maxminddb:"location"`
 }
 
-// Geo returns the Geodata of a given IP address.
+// Geo returns the Geodata of a given IP address, found in the first database
+// that has a record of it. The Geodata is empty when none has. It may come
+// from the cache, so it must not be modified.
 func Geo(ip net.IP) (*Geodata, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	key := cacheKey(ip)
+	if v, ok := geoCache.get(key); ok {
+		return v.(*Geodata), nil
+	}
+	record, err := lookupGeo(ip)
+	if err == nil {
+		geoCache.add(key, record)
+	}
+	return record, err
+}
+
+func lookupGeo(ip net.IP) (*Geodata, error) {
 	var record Geodata
-	err := reader.Lookup(ip, &record)
+	var err error
+	for _, r := range readers {
+		var offset uintptr
+		offset, err = r.LookupOffset(ip)
+		if err != nil || offset == maxmind.NotFound {
+			continue
+		}
+		return &record, r.Decode(offset, &record)
+	}
 	return &record, err
 }
 
-// Info returns a string with information about the current database.
-func Info() string {
-	m := reader.Metadata
+// ASNdata is a struct with the autonomous system data that we need from the
+// GeoLite2-ASN database.
+type ASNdata struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
 
-	return fmt.Sprintf("%s %d.%d (%s)",
-		m.Description["en"],
-		m.BinaryFormatMajorVersion,
-		m.BinaryFormatMinorVersion,
-		time.Unix(int64(m.BuildEpoch), 0).UTC().Format(time.RFC822))
+// ASN returns the ASNdata of a given IP address. The ASNdata is empty when no
+// ASN database was opened or it has no record of the address. It may come
+// from the cache, so it must not be modified.
+func ASN(ip net.IP) (*ASNdata, error) {
+	var record ASNdata
+	mu.RLock()
+	defer mu.RUnlock()
+	if asnReader == nil {
+		return &record, nil
+	}
+	key := cacheKey(ip)
+	if v, ok := asnCache.get(key); ok {
+		return v.(*ASNdata), nil
+	}
+	err := asnReader.Lookup(ip, &record)
+	if err == nil {
+		asnCache.add(key, &record)
+	}
+	return &record, err
+}
+
+// Info returns a string with information about the current databases,
+// including when they were built.
+func Info() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	rs := readers
+	if asnReader != nil {
+		rs = append(rs[:len(rs):len(rs)], asnReader)
+	}
+	infos := make([]string, len(rs))
+	for i, r := range rs {
+		m := r.Metadata
+		name := m.Description["en"]
+		if name == "" {
+			name = m.DatabaseType
+		}
+		infos[i] = fmt.Sprintf("%s %d.%d (%s)",
+			name,
+			m.BinaryFormatMajorVersion,
+			m.BinaryFormatMinorVersion,
+			time.Unix(int64(m.BuildEpoch), 0).UTC().Format(time.RFC822))
+	}
+	return strings.Join(infos, ", ")
 }
@@ -1,8 +1,14 @@
 package geoip_test
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sevein/nfdmp2rds/geoip"
 )
@@ -27,3 +33,186 @@ func TestLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestOpen(t *testing.T) {
+	if err := geoip.Open("testdata/city.mmdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer geoip.Open()
+
+	var tests = []struct {
+		input       string
+		city        string
+		subdivision string
+		postal      string
+		radius      uint16
+	}{
+		{"142.58.103.21", "Burnaby", "BC", "V5A", 20},
+		{"2001:4860:4860::8888", "Mountain View", "CA", "94043", 100},
+		{"217.12.24.33", "", "", "", 0},
+	}
+	for _, tt := range tests {
+		r, err := geoip.Geo(net.ParseIP(tt.input))
+		if err != nil {
+			t.Error(err)
+		}
+		var subdivision string
+		if len(r.Subdivisions) > 0 {
+			subdivision = r.Subdivisions[0].IsoCode
+		}
+		if r.City.Names["en"] != tt.city || subdivision != tt.subdivision || r.Postal.Code != tt.postal || r.Location.AccuracyRadius != tt.radius {
+			t.Errorf("lookup(%s): expected %s %s %s %d, actual %s %s %s %d", tt.input,
+				tt.city, tt.subdivision, tt.postal, tt.radius,
+				r.City.Names["en"], subdivision, r.Postal.Code, r.Location.AccuracyRadius)
+		}
+	}
+
+	if err := geoip.Open("testdata/missing.mmdb"); err == nil {
+		t.Error("open: expected error")
+	}
+}
+
+func TestASN(t *testing.T) {
+	r, err := geoip.ASN(net.ParseIP("142.58.103.21"))
+	if err != nil || r.Number != 0 {
+		t.Errorf("lookup: expected no ASN database, actual %d (%v)", r.Number, err)
+	}
+
+	if err := geoip.OpenASN("testdata/asn.mmdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer geoip.OpenASN("")
+	var tests = []struct {
+		input  string
+		number uint32
+		org    string
+	}{
+		{"142.58.103.21", 271, "BCNET"},
+		{"2001:4860:4860::8888", 15169, "GOOGLE"},
+		{"10.0.0.1", 0, ""},
+	}
+	for _, tt := range tests {
+		r, err := geoip.ASN(net.ParseIP(tt.input))
+		if err != nil {
+			t.Error(err)
+		}
+		if r.Number != tt.number || r.Organization != tt.org {
+			t.Errorf("lookup(%s): expected %d %s, actual %d %s", tt.input, tt.number, tt.org, r.Number, r.Organization)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "city.mmdb")
+	data, err := ioutil.ReadFile("testdata/city.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := geoip.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer geoip.Open()
+
+	// Look up while the database is reloaded.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				r, err := geoip.Geo(net.ParseIP("142.58.103.21"))
+				if err != nil || r.City.Names["en"] != "Burnaby" {
+					t.Errorf("lookup: expected Burnaby, actual %s (%v)", r.City.Names["en"], err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := geoip.Reload(); err != nil {
+			t.Error(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if geoip.Modified() {
+		t.Error("modified: expected false")
+	}
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if !geoip.Modified() {
+		t.Error("modified: expected true")
+	}
+
+	// A broken file does not replace the database in use.
+	if err := ioutil.WriteFile(path, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := geoip.Reload(); err == nil {
+		t.Error("reload: expected error")
+	}
+	if info := geoip.Info(); !strings.HasPrefix(info, "Test City database") {
+		t.Errorf("info: expected the city database, actual %s", info)
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	ip := net.ParseIP("217.12.24.33")
+	geoip.Geo(ip)
+	hits, misses := geoip.CacheStats()
+	r, err := geoip.Geo(ip)
+	if err != nil || r.Country.IsoCode != "ES" {
+		t.Errorf("lookup: expected ES, actual %s (%v)", r.Country.IsoCode, err)
+	}
+	if h, m := geoip.CacheStats(); h != hits+1 || m != misses {
+		t.Errorf("stats: expected %d hits and %d misses, actual %d and %d", hits+1, misses, h, m)
+	}
+}
+
+// BenchmarkGeo looks up the endpoints of flows, which are few compared to the
+// number of flows, with and without the cache.
+func BenchmarkGeo(b *testing.B) {
+	ips := make([]net.IP, 256)
+	for i := range ips {
+		ips[i] = net.IPv4(142, 58, byte(i), 21)
+		if i%2 == 1 {
+			ips[i] = net.IPv4(217, 12, 24, byte(i))
+		}
+	}
+	for _, bm := range []struct {
+		name string
+		size int
+	}{
+		{"Uncached", 0},
+		{"Cached", geoip.DefaultCacheSize},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			geoip.SetCacheSize(bm.size)
+			defer geoip.SetCacheSize(geoip.DefaultCacheSize)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					geoip.Geo(ips[i%len(ips)])
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sevein/nfdmp2rds/entry"
+	"github.com/sevein/nfdmp2rds/nfcapd"
+)
+
+var readers = flag.Int("readers", 2, "Number of inputs read at the same time")
+
+// counts keeps count of the entries processed. It is updated atomically by
+// the digesters.
+type counts struct {
+	written int64
+	failed  int64
+	invalid int64
+}
+
+func (c *counts) add(d counts) {
+	atomic.AddInt64(&c.written, d.written)
+	atomic.AddInt64(&c.failed, d.failed)
+	atomic.AddInt64(&c.invalid, d.invalid)
+}
+
+// input is a file, directory or stdin given in the command line, with the
+// counts of its entries.
+type input struct {
+	counts
+	path string
+	info os.FileInfo
+	err  error // why it could not be read
+}
+
+// record is a line or a flow of the input together with its position, so the
+// digesters can acknowledge it to the checkpointer once it has been dealt
+// with. Flows read from nfcapd files are numbered like lines. Lines are in the
+// layout given, or in the pipe format when it is nil.
+type record struct {
+	line     string
+	layout   *entry.Layout
+	flow     *entry.Flow
+	num      int64 // line number, starting at 1
+	offset   int64 // offset of the byte following the line
+	progress *progress
+	input    *input // nil when collecting
+}
+
+// String returns the line of the record, or its flow in the nfdump pipe
+// format.
+func (r record) String() string {
+	if r.flow != nil {
+		return r.flow.String()
+	}
+	return r.line
+}
+
+// ack acknowledges the record. ok is false when it could not be delivered.
+func (r record) ack(ok bool) {
+	r.progress.ack(r.num, r.offset, ok)
+}
+
+// count adds the outcome of the record to the totals, and to those of its
+// input.
+func (r record) count(d counts) {
+	stats.add(d)
+	if r.input != nil {
+		r.input.add(d)
+	}
+}
+
+// expandInputs returns the paths of the inputs given in the command line,
+// expanding the glob patterns that do not name a file themselves, like the
+// shell does when they are not quoted. Paths given twice are read once.
+func expandInputs(args []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		matches := []string{arg}
+		if _, err := os.Stat(arg); err != nil && strings.ContainsAny(arg, "*?[") {
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// parser starts goroutines to read the inputs, -readers at a time or all of
+// them with -follow, and send each line or flow found on the record channel.
+// An input that can not be read does not stop the others, and its error is
+// kept in the input. Once the inputs are read, parser sends the error of the
+// input on the error channel if there is only one, or nil. If done is closed,
+// parser abandons its work. Closing stop ends -follow.
+func parser(done, stop <-chan struct{}, inputs []*input) (<-chan record, <-chan error) {
+	lines := make(chan record)
+	errc := make(chan error, 1)
+
+	queue := make(chan *input, len(inputs))
+	for _, in := range inputs {
+		queue <- in
+	}
+	close(queue)
+
+	n := *readers
+	if *follow || n > len(inputs) {
+		n = len(inputs)
+	}
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for in := range queue {
+				in.read(done, stop, lines, len(inputs) > 1)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+		if len(inputs) == 1 {
+			errc <- inputs[0].err
+		} else {
+			errc <- nil
+		}
+	}()
+	return lines, errc
+}
+
+// read reads the input, which is a directory of nfcapd files or any other
+// file. With -resume, regular files already processed are skipped, unless
+// they are followed since they may have grown. When the input is one of many,
+// its errors are logged.
+func (in *input) read(done, stop <-chan struct{}, lines chan<- record, many bool) {
+	select {
+	case <-done:
+		return
+	case <-stop:
+		return
+	default:
+	}
+	if in.info.IsDir() {
+		in.err = parseDir(done, stop, in, lines)
+	} else if !*follow && in.path != "-" && in.info.Mode().IsRegular() && checkpoints.done(checkpointKey(in.path)) {
+		if *verbose {
+			logger.Printf("Skipping %s, already processed.", in.path)
+		}
+		return
+	} else {
+		if many {
+			logger.Printf("Reading %s.", in.path)
+		}
+		in.err = parse(done, stop, in, in.path, lines)
+	}
+	if in.err != nil && many {
+		logger.Printf("%s could not be read: %s", in.path, in.err)
+	}
+}
+
+// parse reads the file of in found at path, or stdin, which may be an nfcapd
+// file or hold nfdump lines, and sends its records to lines. Compressed input
+// is decompressed, and its offsets are those of the decompressed data. With
+// -resume, it skips the records already delivered.
+func parse(done, stop <-chan struct{}, in *input, path string, lines chan<- record) error {
+	key := checkpointKey(path)
+	proto := record{progress: checkpoints.track(key, *resume), input: in}
+	start := proto.progress.position()
+
+	file, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if start.Line > 0 {
+		logger.Printf("Resuming %s after record %d.", path, start.Line)
+	}
+
+	br := bufio.NewReader(file)
+	dr, compression, err := decompressor(br)
+	if err != nil {
+		return err
+	}
+	if dr != nil {
+		defer dr.Close()
+		if *follow {
+			logger.Printf("%s is compressed with %s, reading it without following.", path, compression)
+		}
+		br = bufio.NewReader(dr)
+	}
+
+	if head, _ := br.Peek(4); nfcapd.Match(head) {
+		err := scanFlows(done, br, proto, start, lines)
+		if err == nfcapd.ErrLayout && path != "-" && dr == nil {
+			logger.Printf("%s: %s, reading it with %s.", path, err, *nfdumpPath)
+			return parseCommand(done, path, proto, start, lines)
+		}
+		return err
+	}
+
+	format := newLineFormat()
+	var n int64
+	if start.Offset > 0 {
+		if n, err = format.header(br); err != nil {
+			return err
+		}
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if dr == nil && info.Mode().IsRegular() {
+		if err := seek(file, start.Offset); err != nil {
+			return err
+		}
+		var r io.Reader = file
+		if *follow {
+			r = &follower{done: done, stop: stop, path: path, file: file, offset: start.Offset}
+		}
+		br.Reset(r)
+		return scan(done, br, key, proto, start, format, *follow, lines)
+	}
+	if err := skip(br, start.Offset-n); err != nil {
+		return err
+	}
+	return scan(done, br, key, proto, start, format, false, lines)
+}
+
+// parseCommand reads the nfcapd file found at path with nfdump.
+func parseCommand(done <-chan struct{}, path string, proto record, start checkpoint, lines chan<- record) error {
+	r, err := nfdump(path)
+	if err != nil {
+		return err
+	}
+	err = skip(r, start.Offset)
+	if err == nil {
+		err = scan(done, r, path, proto, start, nil, false, lines)
+	}
+	if cerr := r.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// scan sends the lines of r, which has been read up to start, to lines as
+// copies of proto, in the layout kept by format. The lines that hold no flow
+// are acknowledged right away, and so are the lines that are too long or
+// hold binary data and the csv headers that can not be read, which are kept
+// in the dead letter file. The input fails on a bad line if -badLines is
+// fail, and if its first header can not be read. When a followed file is
+// truncated or replaced, its progress is tracked anew, and when the follower
+// stops, the file is not finished since it may grow again.
+func scan(done <-chan struct{}, r io.Reader, key string, proto record, start checkpoint, format *lineFormat, followed bool, lines chan<- record) error {
+	pos := start
+	for {
+		lr := newLineReader(r, *maxLineSize)
+		var err error
+		for {
+			var line []byte
+			var n int64
+			line, n, err = lr.next()
+			if _, bad := err.(*badLineError); err != nil && !bad {
+				break
+			}
+			pos.Line++
+			pos.Offset += n
+			rec := proto
+			rec.line, rec.num, rec.offset = string(line), pos.Line, pos.Offset
+			skip := false
+			if err == nil {
+				skip, err = format.skip(rec.line)
+				if err != nil && !format.headed {
+					return fmt.Errorf("line %d: %s", pos.Line, err)
+				}
+			} else if *badLines == "fail" {
+				return fmt.Errorf("line %d: %s", pos.Line, err)
+			}
+			if err != nil {
+				logger.Printf("Error processing entry: %s line %d: %s", key, pos.Line, err)
+				rec.count(counts{invalid: 1})
+				deadLetters.add(rec, err)
+				skip = true
+			}
+			if skip {
+				rec.ack(true)
+				continue
+			}
+			rec.layout = format.current()
+			select {
+			case <-done:
+				return nil
+			case lines <- rec:
+			}
+		}
+		switch err {
+		case io.EOF:
+			if !followed {
+				proto.progress.finish(pos.Line)
+			}
+			return nil
+		case errTruncated, errRotated:
+			logger.Printf("%s: %s, reading it from the beginning.", key, err)
+			proto.progress = checkpoints.track(key, false)
+			pos = checkpoint{}
+			if format != nil {
+				format = newLineFormat()
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// scanFlows sends the flows of the nfcapd file read from r to lines as copies
+// of proto. The flows already delivered up to start are read again and
+// discarded, and the offsets recorded are those of the blocks.
+func scanFlows(done <-chan struct{}, r io.Reader, proto record, start checkpoint, lines chan<- record) error {
+	rd, err := nfcapd.NewReader(r)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for {
+		f, err := rd.Read()
+		if err == io.EOF {
+			proto.progress.finish(n)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if n++; n <= start.Line {
+			continue
+		}
+		rec := proto
+		rec.flow, rec.num, rec.offset = f, n, rd.Offset()
+		select {
+		case <-done:
+			return nil
+		case lines <- rec:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inputs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.txt", "b.txt", "c.gz", "f[1].txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		args  []string
+		paths []string
+		err   bool
+	}{
+		{[]string{"a.txt"}, []string{"a.txt"}, false},
+		{[]string{"*.txt"}, []string{"a.txt", "b.txt", "f[1].txt"}, false},
+		{[]string{"a.txt", "*.txt", "a.txt"}, []string{"a.txt", "b.txt", "f[1].txt"}, false},
+		{[]string{"sub"}, []string{"sub"}, false},
+		{[]string{"*"}, []string{"a.txt", "b.txt", "c.gz", "f[1].txt", "sub"}, false},
+		{[]string{"?.gz", "sub"}, []string{"c.gz", "sub"}, false},
+		{[]string{"f[1].txt"}, []string{"f[1].txt"}, false},
+		{[]string{"missing.txt"}, []string{"missing.txt"}, false},
+		{[]string{"*.log"}, nil, true},
+		{[]string{"[a.txt"}, nil, true},
+	}
+	join := func(names []string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+	for _, tt := range tests {
+		paths, err := expandInputs(join(tt.args))
+		if (err != nil) != tt.err {
+			t.Errorf("expandInputs(%v): expected error %t, actual %v", tt.args, tt.err, err)
+			continue
+		}
+		if expec := join(tt.paths); !reflect.DeepEqual(paths, expec) {
+			t.Errorf("expandInputs(%v): expected %v, actual %v", tt.args, expec, paths)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	maxLineSize = flag.Int("maxLineSize", 64*1024, "Longest line read, in bytes")
+	badLines    = flag.String("badLines", "skip", "What to do with the lines longer than -maxLineSize or holding binary data: skip, keeping them in the dead letter file, or fail")
+)
+
+// badLineError is returned by lineReader for the lines that are too long, or
+// that hold binary data, i.e. NUL bytes or invalid UTF-8.
+type badLineError struct {
+	size   int64 // 0 unless the line is too long
+	binary bool
+}
+
+func (e *badLineError) Error() string {
+	if e.binary {
+		return "line holds binary data"
+	}
+	return fmt.Sprintf("line too long (%d bytes)", e.size)
+}
+
+// lineReader reads lines like bufio.Scanner, but a line longer than max does
+// not stop the read. Its first max bytes are returned with a *badLineError
+// instead, and the rest is discarded.
+type lineReader struct {
+	r   *bufio.Reader
+	max int
+	buf []byte
+}
+
+func newLineReader(r io.Reader, max int) *lineReader {
+	return &lineReader{r: bufio.NewReader(r), max: max}
+}
+
+// next returns the next line, without its terminator, and the number of
+// bytes consumed, terminator included. The line is only valid until the next
+// call. At the end of the input, next returns io.EOF.
+func (lr *lineReader) next() ([]byte, int64, error) {
+	lr.buf = lr.buf[:0]
+	var n int64
+	var terminated bool
+	for {
+		chunk, err := lr.r.ReadSlice('\n')
+		n += int64(len(chunk))
+
+		// Keep up to one byte more than max, which may be the terminator.
+		if room := lr.max + 1 - len(lr.buf); room > 0 {
+			if len(chunk) > room {
+				chunk = chunk[:room]
+			}
+			lr.buf = append(lr.buf, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && (err != io.EOF || n == 0) {
+			return nil, n, err
+		}
+		terminated = err == nil
+		break
+	}
+
+	size := n
+	if terminated {
+		size--
+	}
+	if size > int64(lr.max) {
+		return lr.buf[:lr.max], n, &badLineError{size: size}
+	}
+	line := bytes.TrimSuffix(lr.buf[:size], []byte{'\r'})
+	if bytes.IndexByte(line, 0) >= 0 || !utf8.Valid(line) {
+		return line, n, &badLineError{binary: true}
+	}
+	return line, n, nil
+}
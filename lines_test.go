@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	type result struct {
+		line string
+		n    int64
+		err  error
+	}
+	long := strings.Repeat("x", 10000)
+	var tests = []struct {
+		name    string
+		input   string
+		max     int
+		results []result
+	}{
+		{"lines", "a\nbc\n", 8, []result{{"a", 2, nil}, {"bc", 3, nil}}},
+		{"empty", "", 8, nil},
+		{"blank line", "\na\n", 8, []result{{"", 1, nil}, {"a", 2, nil}}},
+		{"crlf", "a\r\nb\r\n", 8, []result{{"a", 3, nil}, {"b", 3, nil}}},
+		{"no final newline", "a\nb", 8, []result{{"a", 2, nil}, {"b", 1, nil}}},
+		{"longest", "01234567\n", 8, []result{{"01234567", 9, nil}}},
+		{"longest with crlf", "0123456\r\n", 8, []result{{"0123456", 9, nil}}},
+		{"too long", "0123456789\na\n", 8, []result{{"01234567", 11, &badLineError{size: 10}}, {"a", 2, nil}}},
+		{"too long at the end", "012345678", 8, []result{{"01234567", 9, &badLineError{size: 9}}}},
+		{"longer than the buffer", long + "\na\n", 5000, []result{{long[:5000], 10001, &badLineError{size: 10000}}, {"a", 2, nil}}},
+		{"nul", "a\x00b\nc\n", 8, []result{{"a\x00b", 4, &badLineError{binary: true}}, {"c", 2, nil}}},
+		{"invalid utf-8", "\xff\xfe\nc\n", 8, []result{{"\xff\xfe", 3, &badLineError{binary: true}}, {"c", 2, nil}}},
+		{"utf-8", "héllo\n", 8, []result{{"héllo", 7, nil}}},
+	}
+	for _, tt := range tests {
+		lr := newLineReader(strings.NewReader(tt.input), tt.max)
+		for _, expec := range tt.results {
+			line, n, err := lr.next()
+			actual := result{string(line), n, err}
+			if !reflect.DeepEqual(actual, expec) {
+				t.Errorf("next(%s): expected %+v, actual %+v", tt.name, expec, actual)
+			}
+		}
+		if _, _, err := lr.next(); err != io.EOF {
+			t.Errorf("next(%s): expected %v, actual %v", tt.name, io.EOF, err)
+		}
+	}
+}
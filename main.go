@@ -1,49 +1,112 @@
 package main
 
 import (
-	"bufio"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"os/signal"
 	"runtime/pprof"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/garyburd/redigo/redis"
-
 	"github.com/sevein/nfdmp2rds/entry"
 	"github.com/sevein/nfdmp2rds/geoip"
+	"github.com/sevein/nfdmp2rds/netflow"
+	"github.com/sevein/nfdmp2rds/sink"
 )
 
 var (
-	logger       = log.New(os.Stderr, "", 0)
-	pool         *redis.Pool
-	redisListKey string
+	logger = log.New(os.Stderr, "", 0)
+	output sink.Sink
 )
 
+// stats keeps count of all the entries processed.
+var stats counts
+
 var (
-	redisServer   = flag.String("redisServer", ":6379", "Redis server")
-	redisPassword = flag.String("redisPassword", "", "Redis password")
-	batchSize     = flag.Int("bsize", 5, "Batch size")
-	cpuprofile    = flag.String("cpuprofile", "", "Write CPU profile to file")
-	flush         = flag.Bool("flush", false, "Delete key beforehand")
-	workers       = flag.Int("workers", 4, "Number of workers")
-	verbose       = flag.Bool("v", false, "Verbose mode")
-	help          = flag.Bool("h", false, "Print command usage help")
+	outputURLs     stringsFlag
+	geoDBs         stringsFlag
+	asnDB          = flag.String("asnDB", "", "Autonomous system database, e.g. GeoLite2-ASN.mmdb, used to fill in AS numbers and organizations")
+	publish        = flag.String("publish", "", "Also publish entries to this Redis channel, where {key} is replaced with redisListKey")
+	redisServer    = flag.String("redisServer", ":6379", "Redis server")
+	redisPassword  = flag.String("redisPassword", "", "Redis password")
+	batchSize      = flag.Int("bsize", 5, "Batch size")
+	flushInterval  = flag.Duration("flushInterval", time.Second, "Write incomplete batches after this long, 0 to disable")
+	cpuprofile     = flag.String("cpuprofile", "", "Write CPU profile to file")
+	flush          = flag.Bool("flush", false, "Delete existing entries beforehand")
+	deadLetterPath = flag.String("deadLetter", "", "Append the lines that could not be parsed or written to this file")
+	checkpointPath = flag.String("checkpoint", "", "Record the progress of the import in this file")
+	resume         = flag.Bool("resume", false, "Skip the input already delivered according to -checkpoint")
+	saveInterval   = flag.Duration("checkpointInterval", 5*time.Second, "Save the checkpoint this often")
+	workers        = flag.Int("workers", 4, "Number of workers")
+	verbose        = flag.Bool("v", false, "Verbose mode")
+	help           = flag.Bool("h", false, "Print command usage help")
 )
 
+func init() {
+	flag.Var(&outputURLs, "output", "Output URL, e.g. redis://:6379/netflow:test001 (repeatable)")
+	flag.Var(&geoDBs, "geoDB", "Geographic database, e.g. GeoLite2-City.mmdb, used instead of the embedded country database (repeatable, looked up in order)")
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run does the work of main and returns the exit code, which is non-zero when
+// any entry could not be written.
+func run() int {
 	flag.Usage = usage
-	flag.Parse()
+	replay := len(os.Args) > 1 && os.Args[1] == "replay"
+	if replay {
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
 	args := flag.Args()
-	if *help || len(args) != 2 {
+	collecting := *netflowAddr != "" || *ipfixAddr != "" || *sflowAddr != ""
+	legacy := len(outputURLs) == 0
+	nargs := 1
+	if collecting {
+		nargs = 0
+	}
+	if legacy {
+		nargs++
+	}
+	if *help || len(args) < nargs || (collecting && (len(args) != nargs || replay)) {
 		flag.Usage()
 		os.Exit(1)
 	}
-	redisListKey = args[0]
-	input := args[1]
+	if legacy {
+		key := args[0]
+		outputURLs = append(outputURLs, redisURL("redis", *redisServer, *redisPassword, key))
+		if *publish != "" {
+			channel := strings.Replace(*publish, "{key}", key, -1)
+			outputURLs = append(outputURLs, redisURL("redis+pubsub", *redisServer, *redisPassword, channel))
+		}
+	} else if *publish != "" {
+		logger.Fatalln("-publish can not be combined with -output, use a redis+pubsub output instead.")
+	}
+	if *resume && *checkpointPath == "" {
+		logger.Fatalln("-resume requires -checkpoint.")
+	}
+	if collecting && (*checkpointPath != "" || *follow) {
+		logger.Fatalln("-checkpoint and -follow can not be combined with -netflow, -ipfix or -sflow.")
+	}
+
+	if *entry.Schema != entry.SchemaV1 && *entry.Schema != entry.SchemaV2 {
+		logger.Fatalf("Unknown schema version: %d.", *entry.Schema)
+	}
+	if err := checkFormat(); err != nil {
+		logger.Fatalf("Invalid -format: %s.", err)
+	}
+	if *maxLineSize <= 0 || (*badLines != "skip" && *badLines != "fail") {
+		logger.Fatalln("-maxLineSize must be positive and -badLines skip or fail.")
+	}
 
 	// Enable CPU profiling
 	if *cpuprofile != "" {
@@ -55,68 +118,204 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	// Print geoip version
-	if !*entry.NoGeo {
+	// Open geographic databases and print their version
+	useGeo := !*entry.NoGeo
+	useASN := !*entry.NoASN && *asnDB != ""
+	if useGeo {
+		if err := geoip.Open(geoDBs...); err != nil {
+			logger.Fatalf("Geographic database could not be opened: %s.", err)
+		}
+	}
+	if useASN {
+		if err := geoip.OpenASN(*asnDB); err != nil {
+			logger.Fatalf("ASN database could not be opened: %s.", err)
+		}
+	}
+	if useGeo || useASN {
+		geoip.SetCacheSize(*geoCacheSize)
 		logger.Printf("Using geographic database: %s", geoip.Info())
+		done := make(chan struct{})
+		defer close(done)
+		go reloadGeo(done)
 	}
 
-	// Create pool of redis connections
-	pool = newPool(*redisServer, *redisPassword)
-	defer pool.Close()
+	// Open outputs
+	outputs := make([]sink.Sink, len(outputURLs))
+	for i, rawurl := range outputURLs {
+		s, err := sink.New(rawurl)
+		if err != nil {
+			logger.Fatalf("Invalid output: %s.", err)
+		}
+		if err := s.Open(); err != nil {
+			logger.Fatalf("%s could not be opened: %s.", s, err)
+		}
+		defer s.Close()
+		outputs[i] = s
+	}
+	output = sink.Multi(outputs...)
 
-	// Delete existing list
+	// Delete existing entries
 	if *flush {
-		if err := delKey(pool, redisListKey); err != nil {
-			logger.Fatalf("Key \"%s\" could not be deleted: %s.", redisListKey, err)
+		for _, s := range outputs {
+			r, ok := s.(sink.Resetter)
+			if !ok {
+				continue
+			}
+			if err := r.Reset(); err != nil {
+				logger.Fatalf("%s could not be flushed: %s.", s, err)
+			}
+			logger.Printf("%s has been flushed.", s)
 		}
-		logger.Printf("Key \"%s\" has been deleted.", redisListKey)
 	}
 
-	// Open file or pipe
-	file, err := openFile(input)
-	if err != nil {
-		logger.Fatalf("Error encountered while reading input: %s.", err)
+	// Check files, directories or pipe, or start listening
+	var inputs []*input
+	var collect *netflow.Collector
+	var err error
+	if collecting {
+		collect, err = listen()
+		if err != nil {
+			logger.Fatalf("Error encountered while listening: %s.", err)
+		}
+		defer collect.Close()
+	} else {
+		first := 0
+		if legacy {
+			first = 1
+		}
+		paths, err := expandInputs(args[first:])
+		if err != nil {
+			logger.Fatalf("Error encountered while reading input: %s.", err)
+		}
+		for _, path := range paths {
+			info, err := statInput(path)
+			if err != nil {
+				logger.Fatalf("Error encountered while reading input: %s.", err)
+			}
+			if replay && info.IsDir() {
+				logger.Fatalln("Only dead letter files can be replayed.")
+			}
+			inputs = append(inputs, &input{path: path, info: info})
+		}
+	}
+
+	// Open dead letter file
+	if *deadLetterPath != "" {
+		for _, in := range inputs {
+			if replay && sameFile(in.info, *deadLetterPath) {
+				logger.Fatalln("-deadLetter can not be the file being replayed.")
+			}
+		}
+		deadLetters, err = openDeadLetter(*deadLetterPath)
+		if err != nil {
+			logger.Fatalf("Dead letter file could not be opened: %s.", err)
+		}
+		defer deadLetters.Close()
+	}
+
+	// Open checkpoint file
+	if *checkpointPath != "" {
+		checkpoints, err = openCheckpointer(*checkpointPath)
+		if err != nil {
+			logger.Fatalf("Checkpoint file could not be opened: %s.", err)
+		}
 	}
-	defer file.Close()
 
-	// Here is where the magic happens!
-	if err := process(file); err != nil {
-		logger.Fatalln(err)
+	// Stop following the input or collecting on the first interrupt, so the
+	// entries read are written and the checkpoint is saved.
+	var stop chan struct{}
+	if *follow || collecting {
+		stop = make(chan struct{})
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigc
+			signal.Stop(sigc)
+			logger.Println("Stopping...")
+			close(stop)
+		}()
+	}
+
+	// Here is where the magic happens! Even if it fails, the entries written
+	// are flushed and the checkpoint is saved, so the import can be resumed.
+	source := func(done <-chan struct{}) (<-chan record, <-chan error) {
+		if collecting {
+			return collector(done, stop, collect)
+		}
+		lines, errc := parser(done, stop, inputs)
+		if replay {
+			lines = replayer(done, lines)
+		}
+		return lines, errc
+	}
+	failed := false
+	if err := process(source); err != nil {
+		logger.Println(err)
+		failed = true
+	}
+	if err := output.Flush(); err != nil {
+		logger.Println("Flush failed:", err)
+		failed = true
+	}
+	if err := checkpoints.save(); err != nil {
+		logger.Println("Checkpoint could not be saved:", err)
+		failed = true
+	}
+	if failed {
+		return 1
 	}
 
 	// Say good-bye!
-	conn := pool.Get()
-	defer conn.Close()
-	logger.Println("Done! nfdmp2rds finished successfully.")
-	count, err := redis.Int64(conn.Do("LLEN", redisListKey))
-	if err != nil {
-		logger.Fatalln("LLEN failed:", err)
+	code := 0
+	if stats.failed > 0 {
+		code = 1
+	}
+	for _, in := range inputs {
+		if in.err != nil {
+			code = 1
+		}
+	}
+	if code != 0 {
+		logger.Println("Done! nfdmp2rds finished with errors.")
+	} else {
+		logger.Println("Done! nfdmp2rds finished successfully.")
+	}
+	if len(inputs) > 1 {
+		for _, in := range inputs {
+			status := ""
+			if in.err != nil {
+				status = fmt.Sprintf(" (could not be read: %s)", in.err)
+			}
+			logger.Printf("%s: written: %d, failed: %d, unrecognized: %d%s.", in.path, in.written, in.failed, in.invalid, status)
+		}
+	}
+	logger.Printf("Entries written: %d, failed: %d, unrecognized: %d.", stats.written, stats.failed, stats.invalid)
+	if useGeo || useASN {
+		hits, misses := geoip.CacheStats()
+		logger.Printf("Geographic lookups cached: %d, decoded: %d.", hits, misses)
 	}
-	logger.Printf("List \"%s\" has now %d entries!", redisListKey, count)
+	for _, s := range outputs {
+		c, ok := s.(sink.Counter)
+		if !ok {
+			continue
+		}
+		count, err := c.Count()
+		if err != nil {
+			logger.Fatalln("Count failed:", err)
+		}
+		logger.Printf("%s has now %d entries!", s, count)
+	}
+	return code
 }
 
-func newPool(server, password string) *redis.Pool {
-	return &redis.Pool{
-		MaxIdle:     3,
-		IdleTimeout: 240 * time.Second,
-		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial("tcp", server)
-			if err != nil {
-				return nil, err
-			}
-			if password != "" {
-				if _, err := c.Do("AUTH", password); err != nil {
-					c.Close()
-					return nil, err
-				}
-			}
-			return c, err
-		},
-		TestOnBorrow: func(c redis.Conn, t time.Time) error {
-			_, err := c.Do("PING")
-			return err
-		},
+// redisURL builds the output URLs used when -output is not given, i.e. a
+// Redis list and optionally a channel in the server of -redisServer.
+func redisURL(scheme, server, password, key string) string {
+	u := url.URL{Scheme: scheme, Host: server, Path: "/" + key}
+	if password != "" {
+		u.User = url.UserPassword("", password)
 	}
+	return u.String()
 }
 
 func openFile(input string) (file *os.File, err error) {
@@ -140,18 +339,31 @@ func openFile(input string) (file *os.File, err error) {
 	return file, nil
 }
 
-func delKey(pool *redis.Pool, key string) (err error) {
-	conn := pool.Get()
-	defer conn.Close()
-	_, err = conn.Do("DEL", key)
-	return err
+// statInput returns the description of the input, which is stdin when input
+// is "-".
+func statInput(input string) (os.FileInfo, error) {
+	if input == "-" {
+		return os.Stdin.Stat()
+	}
+	return os.Stat(input)
+}
+
+// sameFile reports whether info describes the file found at path.
+func sameFile(info os.FileInfo, path string) bool {
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(info, pi)
 }
 
-func process(file *os.File) error {
+// process runs the pipeline over the records sent by source, which sends the
+// result of its work on the error channel once the record channel is closed.
+func process(source func(done <-chan struct{}) (<-chan record, <-chan error)) error {
 	done := make(chan struct{})
 	defer close(done)
 
-	lines, errc := parser(done, file)
+	lines, errc := source(done)
 	logger.Println("Parsing has started...")
 
 	// Start a fixed number of goroutines to digest lines.
@@ -166,6 +378,24 @@ func process(file *os.File) error {
 	}
 	logger.Printf("Number of workers running: %d.", *workers)
 
+	// Save the checkpoint periodically while the workers run.
+	if checkpoints != nil && *saveInterval > 0 {
+		ticker := time.NewTicker(*saveInterval)
+		defer ticker.Stop()
+		go func() {
+			for {
+				select {
+				case <-ticker.C:
+					if err := checkpoints.save(); err != nil {
+						logger.Printf("Checkpoint could not be saved: %s", err)
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
 	go func() {
 		wg.Wait()
 		close(errorsc)
@@ -186,76 +416,137 @@ func process(file *os.File) error {
 	return nil
 }
 
-// parser starts a goroutine to scan the file and send each line found on the
-// string channel. It sends the result of the scan on the error channel. If
-// done is closed, parser abandons its work.
-func parser(done <-chan struct{}, file *os.File) (<-chan string, <-chan error) {
-	lines := make(chan string)
-	errc := make(chan error, 1)
-	go func() {
-		// Close the lines channel after this function returns.
-		defer close(lines)
+// digester marshals the lines received and writes them to the output in
+// batches of -bsize entries. Incomplete batches are written every
+// -flushInterval so entries are not held back when the input is slow.
+func digester(done <-chan struct{}, lines <-chan record, c chan<- error) {
+	// The records of the batch are kept for the dead letter file and the
+	// checkpointer.
+	batch := make([][]byte, 0, *batchSize)
+	recs := make([]record, 0, *batchSize)
+
+	var tick <-chan time.Time
+	if *flushInterval > 0 {
+		ticker := time.NewTicker(*flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			select {
-			case <-done:
-				return
-			case lines <- scanner.Text():
-			}
+	report := func(err error) bool {
+		select {
+		case c <- err:
+			return true
+		case <-done:
+			return false
 		}
-		errc <- scanner.Err()
-	}()
-	return lines, errc
-}
-
-func digester(done <-chan struct{}, lines <-chan string, c chan<- error) {
-	conn := pool.Get()
-	defer conn.Close()
-
-	// digester keeps count of entries pushed so it can be done in batches.
-	pushed := 0
+	}
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		err := write(batch, recs)
+		batch, recs = batch[:0], recs[:0]
+		return report(err)
+	}
 
-	for line := range lines {
+	for {
 		select {
-		case c <- push(line, &pushed, conn):
+		case rec, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			j, err := marshal(rec)
+			if err != nil {
+				rec.count(counts{invalid: 1})
+				deadLetters.add(rec, err)
+				rec.ack(true)
+				if !report(err) {
+					return
+				}
+				continue
+			}
+			batch = append(batch, j)
+			recs = append(recs, rec)
+			if len(batch) >= *batchSize && !flush() {
+				return
+			}
+		case <-tick:
+			if !flush() {
+				return
+			}
 		case <-done:
 			return
 		}
 	}
 }
 
-func push(line string, pushed *int, conn redis.Conn) error {
-	e, err := entry.NewNfdumpEntry(line)
-	if err != nil {
-		return err
+func marshal(rec record) ([]byte, error) {
+	if rec.flow != nil {
+		return entry.NewFlowEntry(rec.flow).Marshal()
 	}
-
-	j, err := e.MarshalJSON()
+	if rec.layout != nil {
+		f, err := rec.layout.Parse(rec.line)
+		if err != nil {
+			return nil, err
+		}
+		return entry.NewFlowEntry(f).Marshal()
+	}
+	e, err := entry.NewNfdumpEntry(rec.line)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return e.Marshal()
+}
 
-	if err := conn.Send("LPUSH", redisListKey, string(j)); err != nil {
-		return err
+// write writes a batch to the output and keeps count of the entries that
+// failed, whose original lines are sent to the dead letter file. The records
+// are acknowledged to the checkpointer unless they were lost. Its error
+// summarizes the failures of the whole batch.
+func write(batch [][]byte, recs []record) error {
+	if *verbose {
+		logger.Printf("Writing %d entries...", len(batch))
 	}
-
-	if *pushed < *batchSize {
-		if err := conn.Flush(); err != nil {
-			return err
-		}
-		*pushed++
-		if *verbose {
-			logger.Println("Flushing...")
+	err := output.Write(batch)
+	failed := 0
+	for i, err := range sink.Errors(err, len(batch)) {
+		ok := true
+		if err != nil {
+			ok = deadLetters.add(recs[i], err)
+			recs[i].count(counts{failed: 1})
+			failed++
+		} else {
+			recs[i].count(counts{written: 1})
 		}
+		recs[i].ack(ok)
+	}
+	if failed == len(batch) {
+		return fmt.Errorf("batch of %d entries could not be written: %s", len(batch), err)
 	}
+	if failed > 0 {
+		return fmt.Errorf("batch of %d entries partially written: %s", len(batch), err)
+	}
+	return nil
+}
+
+// stringsFlag is a flag that can be given multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
 
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
 	return nil
 }
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: nfdmp2rds [options] redisListKey file\n")
-	fmt.Fprintf(os.Stderr, "(redisListKey and file mandatory)\n\n")
+	fmt.Fprintf(os.Stderr, "Usage: nfdmp2rds [options] redisListKey file...\n")
+	fmt.Fprintf(os.Stderr, "       nfdmp2rds [options] -output url file...\n")
+	fmt.Fprintf(os.Stderr, "       nfdmp2rds [options] (-netflow addr | -ipfix addr | -sflow addr) (redisListKey | -output url)\n")
+	fmt.Fprintf(os.Stderr, "       nfdmp2rds replay [options] (redisListKey | -output url) deadLetterFile...\n")
+	fmt.Fprintf(os.Stderr, "(redisListKey or -output, and file mandatory)\n\n")
 	fmt.Fprintf(os.Stderr, "Flags (options):\n")
 	flag.PrintDefaults()
 	os.Exit(2)
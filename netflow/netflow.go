@@ -0,0 +1,303 @@
+// Package netflow receives and decodes the packets sent by NetFlow v5 and v9
+// and IPFIX exporters.
+package netflow
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+var (
+	// ErrVersion is returned when a packet is not in a version of NetFlow
+	// that is supported.
+	ErrVersion = errors.New("netflow: unsupported version")
+
+	// ErrShortPacket is returned when a packet is shorter than its header
+	// says.
+	ErrShortPacket = errors.New("netflow: short packet")
+
+	// ErrClosed is returned by Collector.Read once the collector is closed.
+	ErrClosed = errors.New("netflow: collector closed")
+)
+
+const (
+	// maxPacketSize is the largest UDP payload.
+	maxPacketSize = 65535
+
+	// DefaultTemplateTimeout is how long the templates of NetFlow v9 and
+	// IPFIX exporters are kept after they were last received.
+	DefaultTemplateTimeout = 30 * time.Minute
+)
+
+// Decoder decodes export packets. It keeps the templates received from NetFlow
+// v9 and IPFIX exporters, which describe the data records of the packets that
+// follow. Decoder is safe for concurrent use.
+type Decoder struct {
+	// TemplateTimeout is how long templates are kept after they were last
+	// received, or 0 to keep them until they are withdrawn.
+	TemplateTimeout time.Duration
+
+	mu        sync.Mutex
+	templates map[templateKey]*template
+	now       func() time.Time
+}
+
+// NewDecoder returns a Decoder with no templates.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		TemplateTimeout: DefaultTemplateTimeout,
+		templates:       make(map[templateKey]*template),
+		now:             time.Now,
+	}
+}
+
+// Decode decodes an export packet sent by exporter and returns its flows.
+// Data records whose template is unknown are dropped, and reported with a
+// *TemplateError along with the flows that could be decoded.
+func (d *Decoder) Decode(b []byte, exporter string) ([]*entry.Flow, error) {
+	if len(b) < 2 {
+		return nil, ErrShortPacket
+	}
+	switch binary.BigEndian.Uint16(b) {
+	case 5:
+		return decodeV5(b)
+	case 9:
+		return d.decodeV9(b, exporter)
+	case 10:
+		return d.decodeIPFIX(b, exporter)
+	}
+	return nil, ErrVersion
+}
+
+// Forget drops the templates of exporter.
+func (d *Decoder) Forget(exporter string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k := range d.templates {
+		if k.exporter == exporter {
+			delete(d.templates, k)
+		}
+	}
+}
+
+// PacketError is returned by Collector.Read when a packet can not be decoded,
+// or only partially.
+type PacketError struct {
+	Addr net.Addr
+	Err  error
+}
+
+func (e *PacketError) Error() string {
+	return fmt.Sprintf("packet from %s: %s", e.Addr, e.Err)
+}
+
+// DecodeFunc decodes a packet sent by exporter and returns its flows, like
+// Decoder.Decode.
+type DecodeFunc func(b []byte, exporter string) ([]*entry.Flow, error)
+
+// Collector receives export packets on UDP sockets, and IPFIX messages on TCP
+// connections, and decodes them with its Decoder.
+type Collector struct {
+	Decoder *Decoder
+
+	mu      sync.Mutex
+	closers map[io.Closer]bool
+	packets chan packet
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// packet holds what Read returns for each packet.
+type packet struct {
+	flows []*entry.Flow
+	err   error
+}
+
+// NewCollector returns a Collector that is not listening yet.
+func NewCollector() *Collector {
+	return &Collector{
+		Decoder: NewDecoder(),
+		closers: make(map[io.Closer]bool),
+		packets: make(chan packet),
+		closed:  make(chan struct{}),
+	}
+}
+
+// ListenUDP starts receiving NetFlow v5 and v9 and IPFIX packets on the UDP
+// address addr, and returns the address where it is listening.
+func (c *Collector) ListenUDP(addr string) (net.Addr, error) {
+	return c.ListenPacket(addr, func(b []byte, exporter string) ([]*entry.Flow, error) {
+		return c.Decoder.Decode(b, exporter)
+	})
+}
+
+// ListenPacket starts receiving packets on the UDP address addr, decoded by
+// decode instead of the Decoder, so the flows of other export protocols can
+// be read along with those of NetFlow. It returns the address where it is
+// listening.
+func (c *Collector) ListenPacket(addr string, decode DecodeFunc) (net.Addr, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c.track(conn)
+	go c.serveUDP(conn, decode)
+	return conn.LocalAddr(), nil
+}
+
+// ListenTCP starts accepting IPFIX connections on the TCP address addr, and
+// returns the address where it is listening. The templates of each
+// connection are dropped when it is closed.
+func (c *Collector) ListenTCP(addr string) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c.track(l)
+	go c.serveTCP(l)
+	return l.Addr(), nil
+}
+
+// Read waits for the next export packet and returns its flows. When the
+// packet can not be decoded, or only partially, the error is a *PacketError
+// and c can still be used. Once c is closed, Read returns ErrClosed.
+func (c *Collector) Read() ([]*entry.Flow, error) {
+	select {
+	case p := <-c.packets:
+		return p.flows, p.err
+	case <-c.closed:
+		return nil, ErrClosed
+	}
+}
+
+// Close stops listening and closes the connections of the exporters. Any
+// blocked Read returns ErrClosed.
+func (c *Collector) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for closer := range c.closers {
+		closer.Close()
+		delete(c.closers, closer)
+	}
+	return nil
+}
+
+func (c *Collector) track(closer io.Closer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closed:
+		closer.Close()
+	default:
+		c.closers[closer] = true
+	}
+}
+
+func (c *Collector) untrack(closer io.Closer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.closers, closer)
+}
+
+// send hands a packet to Read. It returns false once c is closed.
+func (c *Collector) send(p packet) bool {
+	select {
+	case c.packets <- p:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// serveUDP decodes the packets received on conn with decode. UDP exporters
+// are told apart by their address, not their port, so they keep their
+// templates when they restart.
+func (c *Collector) serveUDP(conn net.PacketConn, decode DecodeFunc) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			c.send(packet{err: err})
+			return
+		}
+		exporter := addr.String()
+		if host, _, err := net.SplitHostPort(exporter); err == nil {
+			exporter = host
+		}
+		flows, err := decode(buf[:n], exporter)
+		if err != nil {
+			err = &PacketError{addr, err}
+		}
+		if !c.send(packet{flows, err}) {
+			return
+		}
+	}
+}
+
+func (c *Collector) serveTCP(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			c.send(packet{err: err})
+			return
+		}
+		c.track(conn)
+		go c.serveConn(conn)
+	}
+}
+
+// serveConn decodes the IPFIX messages received on conn, which are delimited
+// by the length found in their header.
+func (c *Collector) serveConn(conn net.Conn) {
+	exporter := conn.RemoteAddr().String()
+	defer c.Decoder.Forget(exporter)
+	defer c.untrack(conn)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		head := make([]byte, ipfixHeaderSize)
+		if _, err := io.ReadFull(r, head); err != nil {
+			if err != io.EOF {
+				c.send(packet{err: &PacketError{conn.RemoteAddr(), err}})
+			}
+			return
+		}
+		var err error
+		length := int(binary.BigEndian.Uint16(head[2:]))
+		if binary.BigEndian.Uint16(head) != 10 {
+			err = ErrVersion
+		} else if length < ipfixHeaderSize {
+			err = ErrShortPacket
+		}
+		if err != nil {
+			// The stream can not be resynchronized.
+			c.send(packet{err: &PacketError{conn.RemoteAddr(), err}})
+			return
+		}
+		msg := make([]byte, length)
+		copy(msg, head)
+		if _, err := io.ReadFull(r, msg[ipfixHeaderSize:]); err != nil {
+			c.send(packet{err: &PacketError{conn.RemoteAddr(), err}})
+			return
+		}
+		flows, err := c.Decoder.Decode(msg, exporter)
+		if err != nil {
+			err = &PacketError{conn.RemoteAddr(), err}
+		}
+		if !c.send(packet{flows, err}) {
+			return
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+// The packets in testdata hold these flows, sent by an exporter that had been
+// up for an hour. v5.bin holds the first two, v9-data.bin all of them and
+// ipfix.bin the first one.
+var expected = []string{
+	"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+	"2|1463425829|17|1463425834|5|17|0|0|0|3232235777|53|0|0|0|3627729233|1024|0|0|1|2|24|8|2|99",
+	"10|1463425829|17|1463425834|5|6|536954976|1214251008|0|34952|443|704648272|1073940490|0|8206|57145|15169|4200000000|70000|41|24|0|5000000000|6000000000",
+}
+
+func readPacket(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func checkFlows(t *testing.T, name string, flows []*entry.Flow, expec []string) {
+	if len(flows) != len(expec) {
+		t.Errorf("%s: expected %d flows, actual %d", name, len(expec), len(flows))
+		return
+	}
+	for i, f := range flows {
+		if actual := f.String(); actual != expec[i] {
+			t.Errorf("%s: expected %s, actual %s", name, expec[i], actual)
+		}
+	}
+}
+
+func TestDecodeV5(t *testing.T) {
+	flows, err := NewDecoder().Decode(readPacket(t, "v5.bin"), "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlows(t, "v5", flows, expected[:2])
+}
+
+func TestDecodeV9(t *testing.T) {
+	d := NewDecoder()
+
+	// Data records are dropped until the template is received.
+	flows, err := d.Decode(readPacket(t, "v9-data.bin"), "192.0.2.1")
+	if _, ok := err.(*TemplateError); !ok || len(flows) != 0 {
+		t.Errorf("v9: expected *TemplateError and no flows, actual %v and %d flows", err, len(flows))
+	}
+
+	if _, err := d.Decode(readPacket(t, "v9-template.bin"), "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	flows, err = d.Decode(readPacket(t, "v9-data.bin"), "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlows(t, "v9", flows, expected)
+
+	// Templates are not shared by exporters.
+	if _, err := d.Decode(readPacket(t, "v9-data.bin"), "192.0.2.2"); err == nil {
+		t.Error("v9: expected error for another exporter")
+	}
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	d := NewDecoder()
+	flows, err := d.Decode(readPacket(t, "ipfix.bin"), "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlows(t, "ipfix", flows, expected[:1])
+
+	// The template is withdrawn, so the records of the next message are
+	// dropped.
+	if _, err := d.Decode(readPacket(t, "ipfix-withdraw.bin"), "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	packet := readPacket(t, "ipfix.bin")
+	setLen := int(binary.BigEndian.Uint16(packet[ipfixHeaderSize+2:]))
+	data := append(packet[:ipfixHeaderSize:ipfixHeaderSize], packet[ipfixHeaderSize+setLen:]...)
+	binary.BigEndian.PutUint16(data[2:], uint16(len(data)))
+	if _, err := d.Decode(data, "192.0.2.1"); err == nil {
+		t.Error("ipfix: expected error after withdrawal")
+	}
+}
+
+// ipfixMessage returns an IPFIX message of domain 1 with a set of id holding
+// the records given, made of 16-bit words.
+func ipfixMessage(id uint16, records ...[]uint16) []byte {
+	var set []byte
+	for _, r := range records {
+		for _, w := range r {
+			set = append(set, byte(w>>8), byte(w))
+		}
+	}
+	b := make([]byte, ipfixHeaderSize+setHeaderSize, ipfixHeaderSize+setHeaderSize+len(set))
+	binary.BigEndian.PutUint16(b[0:], 10)
+	binary.BigEndian.PutUint16(b[2:], uint16(cap(b)))
+	binary.BigEndian.PutUint32(b[12:], 1)
+	binary.BigEndian.PutUint16(b[16:], id)
+	binary.BigEndian.PutUint16(b[18:], uint16(setHeaderSize+len(set)))
+	return append(b, set...)
+}
+
+func TestOptionsWithdrawal(t *testing.T) {
+	d := NewDecoder()
+	if _, err := d.Decode(ipfixMessage(ipfixTemplateSet, []uint16{256, 1, 8, 4}), "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Options templates have an id, a field count, a scope field count and
+	// the fields, while their withdrawals only have an id and no fields.
+	var tests = []struct {
+		name    string
+		records [][]uint16
+		present []uint16
+		absent  []uint16
+	}{
+		{
+			"template",
+			[][]uint16{{300, 1, 1, 149, 4}},
+			[]uint16{256, 300},
+			nil,
+		},
+		{
+			"withdrawal followed by a template",
+			[][]uint16{{300, 0}, {301, 2, 1, 149, 4, 41, 8}},
+			[]uint16{256, 301},
+			[]uint16{300},
+		},
+		{
+			"withdrawal of all the options templates",
+			[][]uint16{{302, 1, 1, 149, 4}, {ipfixOptionsSet, 0}},
+			[]uint16{256},
+			[]uint16{300, 301, 302},
+		},
+	}
+	for _, tt := range tests {
+		if _, err := d.Decode(ipfixMessage(ipfixOptionsSet, tt.records...), "192.0.2.1"); err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		for _, id := range tt.present {
+			if d.template(templateKey{"192.0.2.1", 1, id}) == nil {
+				t.Errorf("%s: expected template %d", tt.name, id)
+			}
+		}
+		for _, id := range tt.absent {
+			if d.template(templateKey{"192.0.2.1", 1, id}) != nil {
+				t.Errorf("%s: expected no template %d", tt.name, id)
+			}
+		}
+	}
+}
+
+func TestTemplateTimeout(t *testing.T) {
+	now := time.Unix(1463425860, 0)
+	d := NewDecoder()
+	d.TemplateTimeout = time.Minute
+	d.now = func() time.Time { return now }
+
+	if _, err := d.Decode(readPacket(t, "v9-template.bin"), "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := d.Decode(readPacket(t, "v9-data.bin"), "192.0.2.1"); err != nil {
+		t.Errorf("timeout: expected no error, actual %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := d.Decode(readPacket(t, "v9-data.bin"), "192.0.2.1"); err == nil {
+		t.Error("timeout: expected error once the template expired")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	packet := readPacket(t, "v5.bin")
+	var tests = []struct {
+		name  string
+		input []byte
+		expec error
+	}{
+		{"empty", nil, ErrShortPacket},
+		{"header", packet[:20], ErrShortPacket},
+		{"records", packet[:len(packet)-1], ErrShortPacket},
+		{"version", append([]byte{0, 1}, packet[2:]...), ErrVersion},
+		{"v9", readPacket(t, "v9-template.bin")[:30], ErrShortPacket},
+		{"ipfix", readPacket(t, "ipfix.bin")[:100], ErrShortPacket},
+	}
+	for _, tt := range tests {
+		if _, err := NewDecoder().Decode(tt.input, "192.0.2.1"); err != tt.expec {
+			t.Errorf("decode(%s): expected %v, actual %v", tt.name, tt.expec, err)
+		}
+	}
+}
+
+func TestCollectorUDP(t *testing.T) {
+	c := NewCollector()
+	defer c.Close()
+	addr, err := c.ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("udp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A packet that can not be decoded does not stop the collector.
+	conn.Write([]byte{0, 5})
+	conn.Write(readPacket(t, "v5.bin"))
+
+	if _, err := c.Read(); err == nil {
+		t.Error("collector: expected error")
+	} else if _, ok := err.(*PacketError); !ok {
+		t.Errorf("collector: expected *PacketError, actual %T", err)
+	}
+	flows, err := c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlows(t, "collector", flows, expected[:2])
+
+	// Close unblocks Read.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.Close()
+	}()
+	if _, err := c.Read(); err != ErrClosed {
+		t.Errorf("collector: expected %v, actual %v", ErrClosed, err)
+	}
+}
+
+func TestCollectorTCP(t *testing.T) {
+	c := NewCollector()
+	defer c.Close()
+	addr, err := c.ListenTCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages are read from the stream whatever the segments they span.
+	packet := readPacket(t, "ipfix.bin")
+	conn.Write(packet[:10])
+	time.Sleep(10 * time.Millisecond)
+	conn.Write(append(packet[10:], packet...))
+
+	for i := 0; i < 2; i++ {
+		flows, err := c.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkFlows(t, "collector", flows, expected[:1])
+	}
+
+	// The templates of the connection are dropped when it is closed.
+	conn.Close()
+	for i := 0; i < 100; i++ {
+		c.Decoder.mu.Lock()
+		n := len(c.Decoder.templates)
+		c.Decoder.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("collector: expected templates to be dropped")
+}
@@ -0,0 +1,60 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+const (
+	v5HeaderSize = 24
+	v5RecordSize = 48
+	v5MaxCount   = 30
+)
+
+// decodeV5 decodes a NetFlow v5 packet. The times of the records are given
+// in milliseconds of uptime of the exporter, and are converted to absolute
+// times using the uptime and the clock of the exporter found in the header.
+func decodeV5(b []byte) ([]*entry.Flow, error) {
+	if len(b) < v5HeaderSize {
+		return nil, ErrShortPacket
+	}
+	count := int(binary.BigEndian.Uint16(b[2:]))
+	if count > v5MaxCount || len(b) < v5HeaderSize+count*v5RecordSize {
+		return nil, ErrShortPacket
+	}
+	uptime := binary.BigEndian.Uint32(b[4:])
+	now := time.Unix(int64(binary.BigEndian.Uint32(b[8:])), int64(binary.BigEndian.Uint32(b[12:])))
+	boot := now.Add(-time.Duration(uptime) * time.Millisecond)
+
+	flows := make([]*entry.Flow, count)
+	for i := range flows {
+		r := b[v5HeaderSize+i*v5RecordSize:]
+		flows[i] = &entry.Flow{
+			SrcAddr:    net.IP(append([]byte(nil), r[0:4]...)),
+			DstAddr:    net.IP(append([]byte(nil), r[4:8]...)),
+			InputSnmp:  uint32(binary.BigEndian.Uint16(r[12:])),
+			OutputSnmp: uint32(binary.BigEndian.Uint16(r[14:])),
+			Packets:    uint64(binary.BigEndian.Uint32(r[16:])),
+			Bytes:      uint64(binary.BigEndian.Uint32(r[20:])),
+			First:      uptimeTime(boot, binary.BigEndian.Uint32(r[24:])),
+			Last:       uptimeTime(boot, binary.BigEndian.Uint32(r[28:])),
+			SrcPort:    binary.BigEndian.Uint16(r[32:]),
+			DstPort:    binary.BigEndian.Uint16(r[34:]),
+			TcpFlags:   r[37],
+			Protocol:   r[38],
+			SrcTos:     r[39],
+			SrcAs:      uint32(binary.BigEndian.Uint16(r[40:])),
+			DstAs:      uint32(binary.BigEndian.Uint16(r[42:])),
+		}
+	}
+	return flows, nil
+}
+
+// uptimeTime returns the time when the exporter that booted at boot had been
+// up for ms milliseconds.
+func uptimeTime(boot time.Time, ms uint32) time.Time {
+	return boot.Add(time.Duration(ms) * time.Millisecond).Truncate(time.Millisecond)
+}
@@ -0,0 +1,416 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+const (
+	v9HeaderSize    = 20
+	ipfixHeaderSize = 16
+	setHeaderSize   = 4
+
+	// Set ids of templates and options templates. Ids from 256 on are data
+	// sets, described by the template with the same id.
+	v9TemplateSet       = 0
+	v9OptionsSet        = 1
+	ipfixTemplateSet    = 2
+	ipfixOptionsSet     = 3
+	minDataSet          = 256
+	variableLength      = 65535
+	enterpriseBit       = 0x8000
+	ipfixWithdrawAllSet = ipfixTemplateSet
+)
+
+// Information elements mapped to the fields of a flow. NetFlow v9 uses the
+// same numbers for them.
+const (
+	ieOctetDeltaCount           = 1
+	iePacketDeltaCount          = 2
+	ieProtocolIdentifier        = 4
+	ieIPClassOfService          = 5
+	ieTCPControlBits            = 6
+	ieSourceTransportPort       = 7
+	ieSourceIPv4Address         = 8
+	ieIngressInterface          = 10
+	ieDestinationTransportPort  = 11
+	ieDestinationIPv4Address    = 12
+	ieEgressInterface           = 14
+	ieBGPSourceAsNumber         = 16
+	ieBGPDestinationAsNumber    = 17
+	ieFlowEndSysUpTime          = 21
+	ieFlowStartSysUpTime        = 22
+	ieSourceIPv6Address         = 27
+	ieDestinationIPv6Address    = 28
+	ieOctetTotalCount           = 85
+	iePacketTotalCount          = 86
+	ieFlowStartSeconds          = 150
+	ieFlowEndSeconds            = 151
+	ieFlowStartMilliseconds     = 152
+	ieFlowEndMilliseconds       = 153
+	ieSystemInitTimeMillisecond = 160
+)
+
+// TemplateError is returned by Decoder.Decode when data records were dropped
+// because their template is unknown, or expired.
+type TemplateError struct {
+	Domain uint32
+	ID     uint16
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("netflow: unknown template %d in domain %d", e.ID, e.Domain)
+}
+
+// templateKey identifies a template. Templates are scoped to the exporter and
+// its source id (NetFlow v9) or observation domain (IPFIX).
+type templateKey struct {
+	exporter string
+	domain   uint32
+	id       uint16
+}
+
+// template describes the data records of a set. The records of options
+// templates are skipped.
+type template struct {
+	fields  []templateField
+	options bool
+	updated time.Time
+}
+
+type templateField struct {
+	id         uint16
+	enterprise uint32
+	length     uint16
+}
+
+// minLength returns the length of the shortest record, counting one byte for
+// variable-length fields.
+func (t *template) minLength() int {
+	n := 0
+	for _, f := range t.fields {
+		if f.length == variableLength {
+			n++
+		} else {
+			n += int(f.length)
+		}
+	}
+	return n
+}
+
+// message holds what the sets of a packet need from its header.
+type message struct {
+	exporter string
+	domain   uint32
+	ipfix    bool
+	export   time.Time
+
+	// boot is when the exporter started, from the uptime of NetFlow v9
+	// headers.
+	boot time.Time
+}
+
+func (d *Decoder) decodeV9(b []byte, exporter string) ([]*entry.Flow, error) {
+	if len(b) < v9HeaderSize {
+		return nil, ErrShortPacket
+	}
+	uptime := binary.BigEndian.Uint32(b[4:])
+	export := time.Unix(int64(binary.BigEndian.Uint32(b[8:])), 0)
+	m := message{
+		exporter: exporter,
+		domain:   binary.BigEndian.Uint32(b[16:]),
+		export:   export,
+		boot:     export.Add(-time.Duration(uptime) * time.Millisecond),
+	}
+	return d.decodeSets(b[v9HeaderSize:], m)
+}
+
+func (d *Decoder) decodeIPFIX(b []byte, exporter string) ([]*entry.Flow, error) {
+	if len(b) < ipfixHeaderSize {
+		return nil, ErrShortPacket
+	}
+	length := int(binary.BigEndian.Uint16(b[2:]))
+	if length < ipfixHeaderSize || len(b) < length {
+		return nil, ErrShortPacket
+	}
+	m := message{
+		exporter: exporter,
+		domain:   binary.BigEndian.Uint32(b[12:]),
+		ipfix:    true,
+		export:   time.Unix(int64(binary.BigEndian.Uint32(b[4:])), 0),
+	}
+	return d.decodeSets(b[ipfixHeaderSize:length], m)
+}
+
+// decodeSets decodes the sets of a packet, in order, since templates can be
+// followed by their data records in the same packet.
+func (d *Decoder) decodeSets(b []byte, m message) ([]*entry.Flow, error) {
+	templateSet, optionsSet := v9TemplateSet, v9OptionsSet
+	if m.ipfix {
+		templateSet, optionsSet = ipfixTemplateSet, ipfixOptionsSet
+	}
+	var flows []*entry.Flow
+	var missing error
+	for len(b) > 0 {
+		if len(b) < setHeaderSize {
+			return nil, ErrShortPacket
+		}
+		id := int(binary.BigEndian.Uint16(b))
+		length := int(binary.BigEndian.Uint16(b[2:]))
+		if length < setHeaderSize || len(b) < length {
+			return nil, ErrShortPacket
+		}
+		set := b[setHeaderSize:length]
+		b = b[length:]
+
+		var err error
+		switch {
+		case id == templateSet:
+			err = d.templateSet(set, m, false)
+		case id == optionsSet:
+			err = d.templateSet(set, m, true)
+		case id >= minDataSet:
+			var fs []*entry.Flow
+			fs, err = d.dataSet(set, m, uint16(id))
+			if _, ok := err.(*TemplateError); ok {
+				missing, err = err, nil
+			}
+			flows = append(flows, fs...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return flows, missing
+}
+
+// templateSet records the templates of a set. In IPFIX, a template with no
+// fields withdraws the template with its id, or all of them.
+func (d *Decoder) templateSet(b []byte, m message, options bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for len(b) >= 4 {
+		key := templateKey{m.exporter, m.domain, binary.BigEndian.Uint16(b)}
+		count := int(binary.BigEndian.Uint16(b[2:]))
+		b = b[4:]
+
+		// A withdrawal has no scope field count, even in options sets.
+		if m.ipfix && count == 0 {
+			d.withdraw(key, options)
+			continue
+		}
+		if options {
+			if len(b) < 2 {
+				return ErrShortPacket
+			}
+			if !m.ipfix {
+				// NetFlow v9 gives the length of the scope and option
+				// fields in bytes.
+				count = (count + int(binary.BigEndian.Uint16(b))) / 4
+			}
+			b = b[2:]
+		}
+		t := &template{options: options, updated: d.now()}
+		for i := 0; i < count; i++ {
+			if len(b) < 4 {
+				return ErrShortPacket
+			}
+			f := templateField{
+				id:     binary.BigEndian.Uint16(b),
+				length: binary.BigEndian.Uint16(b[2:]),
+			}
+			b = b[4:]
+			if m.ipfix && f.id&enterpriseBit != 0 {
+				if len(b) < 4 {
+					return ErrShortPacket
+				}
+				f.id &^= enterpriseBit
+				f.enterprise = binary.BigEndian.Uint32(b)
+				b = b[4:]
+			}
+			t.fields = append(t.fields, f)
+		}
+		if t.minLength() == 0 {
+			return ErrShortPacket
+		}
+		d.templates[key] = t
+	}
+	return nil
+}
+
+// withdraw removes the template of key, or every template of its exporter
+// and domain if its id is the id of the set, i.e. of template sets or of
+// options template sets when options is true.
+func (d *Decoder) withdraw(key templateKey, options bool) {
+	all := uint16(ipfixWithdrawAllSet)
+	if options {
+		all = ipfixOptionsSet
+	}
+	if key.id != all {
+		delete(d.templates, key)
+		return
+	}
+	for k, t := range d.templates {
+		if k.exporter == key.exporter && k.domain == key.domain && t.options == options {
+			delete(d.templates, k)
+		}
+	}
+}
+
+// template returns the template of key, unless it expired.
+func (d *Decoder) template(key templateKey) *template {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.templates[key]
+	if t != nil && d.TemplateTimeout > 0 && d.now().Sub(t.updated) > d.TemplateTimeout {
+		delete(d.templates, key)
+		return nil
+	}
+	return t
+}
+
+// dataSet decodes the records of a data set. What is left after the last
+// record is padding.
+func (d *Decoder) dataSet(b []byte, m message, id uint16) ([]*entry.Flow, error) {
+	t := d.template(templateKey{m.exporter, m.domain, id})
+	if t == nil {
+		return nil, &TemplateError{m.domain, id}
+	}
+	if t.options {
+		return nil, nil
+	}
+	var flows []*entry.Flow
+	for min := t.minLength(); len(b) >= min; {
+		f, n := decodeRecord(b, t, m)
+		if f == nil {
+			break
+		}
+		flows = append(flows, f)
+		b = b[n:]
+	}
+	return flows, nil
+}
+
+// decodeRecord decodes a data record and returns its length, or a nil flow
+// if b is too short.
+func decodeRecord(b []byte, t *template, m message) (*entry.Flow, int) {
+	f := &entry.Flow{First: m.export, Last: m.export}
+	var start, end, init uint64
+	var startUp, endUp, haveUp bool
+	n := 0
+	for _, field := range t.fields {
+		length := int(field.length)
+		if field.length == variableLength {
+			if len(b) < n+1 {
+				return nil, 0
+			}
+			length = int(b[n])
+			n++
+			if length == 255 {
+				if len(b) < n+2 {
+					return nil, 0
+				}
+				length = int(binary.BigEndian.Uint16(b[n:]))
+				n += 2
+			}
+		}
+		if len(b) < n+length {
+			return nil, 0
+		}
+		v := b[n : n+length]
+		n += length
+		if field.enterprise != 0 {
+			continue
+		}
+
+		switch field.id {
+		case ieOctetDeltaCount, ieOctetTotalCount:
+			f.Bytes = uintN(v)
+		case iePacketDeltaCount, iePacketTotalCount:
+			f.Packets = uintN(v)
+		case ieProtocolIdentifier:
+			f.Protocol = uint8(uintN(v))
+		case ieIPClassOfService:
+			f.SrcTos = uint8(uintN(v))
+		case ieTCPControlBits:
+			f.TcpFlags = uint8(uintN(v))
+		case ieSourceTransportPort:
+			f.SrcPort = uint16(uintN(v))
+		case ieDestinationTransportPort:
+			f.DstPort = uint16(uintN(v))
+		case ieSourceIPv4Address, ieSourceIPv6Address:
+			f.SrcAddr = ipaddr(v)
+		case ieDestinationIPv4Address, ieDestinationIPv6Address:
+			f.DstAddr = ipaddr(v)
+		case ieIngressInterface:
+			f.InputSnmp = uint32(uintN(v))
+		case ieEgressInterface:
+			f.OutputSnmp = uint32(uintN(v))
+		case ieBGPSourceAsNumber:
+			f.SrcAs = uint32(uintN(v))
+		case ieBGPDestinationAsNumber:
+			f.DstAs = uint32(uintN(v))
+		case ieFlowStartSysUpTime:
+			start, startUp = uintN(v), true
+		case ieFlowEndSysUpTime:
+			end, endUp = uintN(v), true
+		case ieSystemInitTimeMillisecond:
+			init, haveUp = uintN(v), true
+		case ieFlowStartSeconds:
+			f.First = time.Unix(int64(uintN(v)), 0)
+		case ieFlowEndSeconds:
+			f.Last = time.Unix(int64(uintN(v)), 0)
+		case ieFlowStartMilliseconds:
+			f.First = msecTime(uintN(v))
+		case ieFlowEndMilliseconds:
+			f.Last = msecTime(uintN(v))
+		}
+	}
+
+	// Uptimes are relative to the boot of the exporter, which IPFIX gives
+	// in the record itself.
+	boot := m.boot
+	if haveUp {
+		boot = msecTime(init)
+	}
+	if !boot.IsZero() {
+		if startUp {
+			f.First = uptimeTime(boot, uint32(start))
+		}
+		if endUp {
+			f.Last = uptimeTime(boot, uint32(end))
+		}
+	}
+	if f.SrcAddr == nil {
+		f.SrcAddr = make(net.IP, net.IPv4len)
+	}
+	if f.DstAddr == nil {
+		f.DstAddr = make(net.IP, len(f.SrcAddr))
+	}
+	return f, n
+}
+
+// uintN decodes an unsigned integer of up to 8 bytes, since IPFIX allows
+// exporters to send integers in fewer bytes than their type.
+func uintN(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+func ipaddr(b []byte) net.IP {
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return nil
+	}
+	return net.IP(append([]byte(nil), b...))
+}
+
+func msecTime(ms uint64) time.Time {
+	return time.Unix(int64(ms/1000), int64(ms%1000)*int64(time.Millisecond))
+}
@@ -0,0 +1,407 @@
+// Package nfcapd reads the binary files written by nfcapd, the capture daemon
+// of nfdump, so they can be processed without converting them with nfdump
+// first. Only the layout version 1 used by nfdump 1.6 is supported. The
+// extensions of a flow are read up to the first one whose size is not known,
+// e.g. the NSEL or latency ones: the fields of the extensions that follow it
+// are left empty.
+package nfcapd
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/pierrec/lz4"
+	"github.com/rasky/go-lzo"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+var (
+	// ErrFormat is returned when the file is not an nfcapd file.
+	ErrFormat = errors.New("nfcapd: not an nfcapd file")
+
+	// ErrLayout is returned when the file uses a layout that is not
+	// supported, e.g. the one introduced by nfdump 1.7.
+	ErrLayout = errors.New("nfcapd: unsupported layout version")
+
+	// ErrCorrupt is returned when a block or a record is malformed.
+	ErrCorrupt = errors.New("nfcapd: corrupt file")
+)
+
+const (
+	magic    = 0xA50C
+	layoutV1 = 1
+
+	// Compression of the data blocks, from the flags of the file header.
+	flagLZO = 0x1
+	flagBZ2 = 0x8
+	flagLZ4 = 0x10
+
+	fileHeaderSize   = 140
+	statRecordSize   = 136
+	blockHeaderSize  = 12
+	commonRecordSize = 32
+
+	dataBlockType2 = 2
+
+	// flagBlockUncompressed is set in the header of the blocks stored
+	// uncompressed in a compressed file.
+	flagBlockUncompressed = 0x1
+
+	extensionMapType = 2
+	commonRecordType = 10
+
+	// Flags of the common record.
+	flagIPv6    = 0x1
+	flagPkts64  = 0x2
+	flagBytes64 = 0x4
+
+	// maxBlockSize is the size of the buffers of nfdump, which bounds the
+	// size of an uncompressed block.
+	maxBlockSize = 5 * 1048576
+)
+
+// Optional extensions of the common record read by the Reader. The others are
+// skipped.
+const (
+	extIOSnmp2 = 4
+	extIOSnmp4 = 5
+	extAS2     = 6
+	extAS4     = 7
+)
+
+// extensionSizes are the sizes of the optional extensions known, by id.
+var extensionSizes = map[uint16]int{
+	4: 4, 5: 8, 6: 4, 7: 8, 8: 4, 9: 4, 10: 16, 11: 4, 12: 16, 13: 4,
+	14: 4, 15: 8, 16: 4, 17: 8, 18: 4, 19: 8, 20: 16, 21: 16, 22: 40,
+	23: 4, 24: 16, 25: 4, 26: 8, 27: 8,
+}
+
+// Match reports whether b starts with the magic number of nfcapd files, in
+// either byte order.
+func Match(b []byte) bool {
+	return len(b) >= 2 && (binary.LittleEndian.Uint16(b) == magic || binary.BigEndian.Uint16(b) == magic)
+}
+
+// Header is the header of an nfcapd file.
+type Header struct {
+	Version   uint16
+	Flags     uint32
+	NumBlocks uint32
+	Ident     string
+}
+
+// Reader reads the flows of an nfcapd file.
+type Reader struct {
+	Header
+
+	r     io.Reader
+	order binary.ByteOrder
+
+	// maps holds the ids of the extensions of each extension map, by map
+	// id.
+	maps map[uint16][]uint16
+
+	block  []byte // the records of the current block not read yet
+	left   uint32 // number of records left in block
+	raw    []byte
+	buf    []byte
+	offset int64
+}
+
+// NewReader reads the header of the nfcapd file read from r and returns a
+// Reader for its flows.
+func NewReader(r io.Reader) (*Reader, error) {
+	var head [fileHeaderSize + statRecordSize]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrFormat
+		}
+		return nil, err
+	}
+	rd := &Reader{
+		r:      r,
+		maps:   make(map[uint16][]uint16),
+		offset: int64(len(head)),
+	}
+	switch {
+	case binary.LittleEndian.Uint16(head[0:]) == magic:
+		rd.order = binary.LittleEndian
+	case binary.BigEndian.Uint16(head[0:]) == magic:
+		rd.order = binary.BigEndian
+	default:
+		return nil, ErrFormat
+	}
+	rd.Version = rd.order.Uint16(head[2:])
+	if rd.Version != layoutV1 {
+		return nil, ErrLayout
+	}
+	rd.Flags = rd.order.Uint32(head[4:])
+	rd.NumBlocks = rd.order.Uint32(head[8:])
+	ident := head[12:fileHeaderSize]
+	if i := bytes.IndexByte(ident, 0); i >= 0 {
+		ident = ident[:i]
+	}
+	rd.Ident = string(ident)
+	return rd, nil
+}
+
+// Offset returns the number of bytes of the file read so far, which is the
+// end of the block being read.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Read returns the next flow of the file, or io.EOF at its end.
+func (r *Reader) Read() (*entry.Flow, error) {
+	for {
+		for r.left > 0 {
+			f, err := r.record()
+			if err != nil {
+				return nil, err
+			}
+			if f != nil {
+				return f, nil
+			}
+		}
+		if err := r.next(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next reads the next data block, skipping blocks of any other type.
+func (r *Reader) next() error {
+	for {
+		var head [blockHeaderSize]byte
+		if _, err := io.ReadFull(r.r, head[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return ErrCorrupt
+			}
+			return err
+		}
+		num := r.order.Uint32(head[0:])
+		size := r.order.Uint32(head[4:])
+		id := r.order.Uint16(head[8:])
+		flags := r.order.Uint16(head[10:])
+		if size > maxBlockSize {
+			return ErrCorrupt
+		}
+		if cap(r.raw) < int(size) {
+			r.raw = make([]byte, size)
+		}
+		data := r.raw[:size]
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrCorrupt
+			}
+			return err
+		}
+		r.offset += int64(len(head)) + int64(size)
+		if id != dataBlockType2 {
+			continue
+		}
+		block := data
+		if flags&flagBlockUncompressed == 0 {
+			var err error
+			if block, err = r.uncompress(data); err != nil {
+				return err
+			}
+		}
+		r.block, r.left = block, num
+		return nil
+	}
+}
+
+// uncompress returns the records of a data block.
+func (r *Reader) uncompress(data []byte) ([]byte, error) {
+	var block []byte
+	var err error
+	switch {
+	case r.Flags&flagLZO != 0:
+		block, err = lzo.Decompress1X(bytes.NewReader(data), len(data), 0)
+	case r.Flags&flagBZ2 != 0:
+		block, err = ioutil.ReadAll(io.LimitReader(bzip2.NewReader(bytes.NewReader(data)), maxBlockSize))
+	case r.Flags&flagLZ4 != 0:
+		if r.buf == nil {
+			r.buf = make([]byte, maxBlockSize)
+		}
+		var n int
+		n, err = lz4.UncompressBlock(data, r.buf)
+		block = r.buf[:n]
+	default:
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("nfcapd: corrupt block: %s", err)
+	}
+	return block, nil
+}
+
+// record reads the next record of the block. It returns a nil flow for
+// records other than flows.
+func (r *Reader) record() (*entry.Flow, error) {
+	if len(r.block) < 4 {
+		return nil, ErrCorrupt
+	}
+	typ := r.order.Uint16(r.block[0:])
+	size := int(r.order.Uint16(r.block[2:]))
+	if size < 4 || size > len(r.block) {
+		return nil, ErrCorrupt
+	}
+	rec := r.block[:size]
+	r.block = r.block[size:]
+	r.left--
+
+	switch typ {
+	case extensionMapType:
+		return nil, r.extensionMap(rec)
+	case commonRecordType:
+		return r.flow(rec)
+	}
+	return nil, nil
+}
+
+// extensionMap records the ids of the extensions listed by an extension map
+// record, which ends with a zero id.
+func (r *Reader) extensionMap(rec []byte) error {
+	if len(rec) < 8 {
+		return ErrCorrupt
+	}
+	id := r.order.Uint16(rec[4:])
+	var ids []uint16
+	for i := 8; i+2 <= len(rec); i += 2 {
+		ext := r.order.Uint16(rec[i:])
+		if ext == 0 {
+			break
+		}
+		ids = append(ids, ext)
+	}
+	r.maps[id] = ids
+	return nil
+}
+
+// flow decodes a common record. The addresses, packets and bytes follow its
+// fixed part, and then the extensions listed by its extension map. The
+// extensions that follow an unknown one can not be found, and are skipped
+// along with it.
+func (r *Reader) flow(rec []byte) (*entry.Flow, error) {
+	if len(rec) < commonRecordSize {
+		return nil, ErrCorrupt
+	}
+	flags := r.order.Uint16(rec[4:])
+	exts, ok := r.maps[r.order.Uint16(rec[6:])]
+	if !ok {
+		return nil, fmt.Errorf("nfcapd: unknown extension map %d", r.order.Uint16(rec[6:]))
+	}
+	f := entry.Flow{
+		First:    ntime(r.order.Uint32(rec[12:]), r.order.Uint16(rec[8:])),
+		Last:     ntime(r.order.Uint32(rec[16:]), r.order.Uint16(rec[10:])),
+		TcpFlags: rec[21],
+		Protocol: rec[22],
+		SrcTos:   rec[23],
+		SrcPort:  r.order.Uint16(rec[24:]),
+		DstPort:  r.order.Uint16(rec[26:]),
+	}
+
+	d := decoder{order: r.order, b: rec[commonRecordSize:]}
+	if flags&flagIPv6 != 0 {
+		f.SrcAddr, f.DstAddr = d.ipv6(), d.ipv6()
+	} else {
+		f.SrcAddr, f.DstAddr = d.ipv4(), d.ipv4()
+	}
+	if flags&flagPkts64 != 0 {
+		f.Packets = d.uint64()
+	} else {
+		f.Packets = uint64(d.uint32())
+	}
+	if flags&flagBytes64 != 0 {
+		f.Bytes = d.uint64()
+	} else {
+		f.Bytes = uint64(d.uint32())
+	}
+extensions:
+	for _, ext := range exts {
+		switch ext {
+		case extIOSnmp2:
+			f.InputSnmp, f.OutputSnmp = uint32(d.uint16()), uint32(d.uint16())
+		case extIOSnmp4:
+			f.InputSnmp, f.OutputSnmp = d.uint32(), d.uint32()
+		case extAS2:
+			f.SrcAs, f.DstAs = uint32(d.uint16()), uint32(d.uint16())
+		case extAS4:
+			f.SrcAs, f.DstAs = d.uint32(), d.uint32()
+		default:
+			size, ok := extensionSizes[ext]
+			if !ok {
+				break extensions
+			}
+			d.skip(size)
+		}
+	}
+	if d.short {
+		return nil, ErrCorrupt
+	}
+	return &f, nil
+}
+
+func ntime(sec uint32, msec uint16) time.Time {
+	return time.Unix(int64(sec), int64(msec)*int64(time.Millisecond))
+}
+
+// decoder reads the values of a record in the byte order of the file,
+// remembering if the record was too short so it can be checked once.
+type decoder struct {
+	order binary.ByteOrder
+	b     []byte
+	short bool
+}
+
+func (d *decoder) next(n int) []byte {
+	if len(d.b) < n {
+		d.short = true
+		d.b = nil
+		return make([]byte, n)
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) skip(n int) {
+	d.next(n)
+}
+
+func (d *decoder) uint16() uint16 {
+	return d.order.Uint16(d.next(2))
+}
+
+func (d *decoder) uint32() uint32 {
+	return d.order.Uint32(d.next(4))
+}
+
+func (d *decoder) uint64() uint64 {
+	return d.order.Uint64(d.next(8))
+}
+
+func (d *decoder) ipv4() net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, d.uint32())
+	return ip
+}
+
+// ipv6 reads an IPv6 address, which nfdump stores as two 64-bit integers,
+// most significant first.
+func (d *decoder) ipv6() net.IP {
+	ip := make(net.IP, net.IPv6len)
+	binary.BigEndian.PutUint64(ip[0:], d.uint64())
+	binary.BigEndian.PutUint64(ip[8:], d.uint64())
+	return ip
+}
@@ -0,0 +1,207 @@
+package nfcapd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The fixtures hold the same flows: an IPv4 flow with 16-bit extensions, an
+// IPv6 flow with 64-bit counters and 32-bit extensions, and a UDP flow, in two
+// data blocks separated by a block of another type.
+var expected = []string{
+	"2|1463425844|692|1463425855|188|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|0|0|10|5256",
+	"10|1463425829|17|1463425834|5|6|536954976|1214251008|0|34952|443|704648272|1073940490|0|8206|57145|15169|4200000000|70000|41|24|0|5000000000|6000000000",
+	"2|1463425829|17|1463425834|5|17|0|0|0|3232235777|53|0|0|0|3627729233|1024|0|0|1|2|24|8|2|99",
+}
+
+func TestRead(t *testing.T) {
+	var tests = []struct {
+		file  string
+		flags uint32
+	}{
+		{"testdata/flows.nfcapd", 0},
+		{"testdata/flows-lzo.nfcapd", flagLZO},
+		{"testdata/flows-bz2.nfcapd", flagBZ2},
+		{"testdata/flows-lz4.nfcapd", flagLZ4},
+	}
+	for _, tt := range tests {
+		file, err := os.Open(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		r, err := NewReader(file)
+		if err != nil {
+			t.Errorf("read(%s): %s", tt.file, err)
+			continue
+		}
+		if r.Flags != tt.flags || r.Ident != "test" {
+			t.Errorf("read(%s): expected flags %d and ident test, actual %d and %s", tt.file, tt.flags, r.Flags, r.Ident)
+		}
+		var actual []string
+		for {
+			f, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("read(%s): %s", tt.file, err)
+				break
+			}
+			actual = append(actual, f.String())
+		}
+		if len(actual) != len(expected) {
+			t.Errorf("read(%s): expected %d flows, actual %d", tt.file, len(expected), len(actual))
+			continue
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("read(%s): expected %s, actual %s", tt.file, expected[i], actual[i])
+			}
+		}
+	}
+}
+
+func TestNewReaderErrors(t *testing.T) {
+	blob, err := ioutil.ReadFile("testdata/flows.nfcapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout2 := append([]byte(nil), blob...)
+	layout2[2] = 2
+
+	var tests = []struct {
+		name  string
+		input []byte
+		expec error
+	}{
+		{"empty", nil, ErrFormat},
+		{"text", bytes.Repeat([]byte("2|1463425844|692|1463425855|188|6\n"), 10), ErrFormat},
+		{"header", blob[:100], ErrFormat},
+		{"layout", layout2, ErrLayout},
+	}
+	for _, tt := range tests {
+		if _, err := NewReader(bytes.NewReader(tt.input)); err != tt.expec {
+			t.Errorf("reader(%s): expected %v, actual %v", tt.name, tt.expec, err)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	blob, err := ioutil.ReadFile("testdata/flows.nfcapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(blob[:len(blob)-10]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		_, err = r.Read()
+	}
+	if err != ErrCorrupt {
+		t.Errorf("truncated: expected %v, actual %v", ErrCorrupt, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		input []byte
+		expec bool
+	}{
+		{[]byte{0x0c, 0xa5, 1, 0}, true},
+		{[]byte{0xa5, 0x0c, 0, 1}, true},
+		{[]byte("2|14"), false},
+		{[]byte{0x0c}, false},
+	}
+	for _, tt := range tests {
+		if actual := Match(tt.input); actual != tt.expec {
+			t.Errorf("match(%v): expected %t, actual %t", tt.input, tt.expec, actual)
+		}
+	}
+}
+
+// blocks calls fn with the header and the data of each block of the nfcapd
+// file held in blob, which is little-endian.
+func blocks(blob []byte, fn func(head, data []byte)) {
+	for i := fileHeaderSize + statRecordSize; i+blockHeaderSize <= len(blob); {
+		head := blob[i : i+blockHeaderSize]
+		size := int(binary.LittleEndian.Uint32(head[4:]))
+		fn(head, blob[i+blockHeaderSize:i+blockHeaderSize+size])
+		i += blockHeaderSize + size
+	}
+}
+
+func TestBlockUncompressed(t *testing.T) {
+	blob, err := ioutil.ReadFile("testdata/flows.nfcapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []uint32{flagLZO, flagBZ2, flagLZ4}
+	for _, flags := range tests {
+		input := append([]byte(nil), blob...)
+		binary.LittleEndian.PutUint32(input[4:], flags)
+		blocks(input, func(head, data []byte) {
+			binary.LittleEndian.PutUint16(head[10:], flagBlockUncompressed)
+		})
+		r, err := NewReader(bytes.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			f, err := r.Read()
+			if err != nil {
+				t.Errorf("read(%d): %s", flags, err)
+				break
+			}
+			if actual := f.String(); actual != expected[i] {
+				t.Errorf("read(%d): expected %s, actual %s", flags, expected[i], actual)
+			}
+		}
+	}
+}
+
+func TestUnknownExtension(t *testing.T) {
+	blob, err := ioutil.ReadFile("testdata/flows.nfcapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replace the AS extension of map 0, used by the first and the last
+	// flows, with an unknown one. The interfaces, which come first, are still
+	// read, but not the AS numbers.
+	blocks(blob, func(head, data []byte) {
+		if binary.LittleEndian.Uint16(head[8:]) != dataBlockType2 {
+			return
+		}
+		typ := binary.LittleEndian.Uint16(data[0:])
+		if typ == extensionMapType && binary.LittleEndian.Uint16(data[4:]) == 0 {
+			binary.LittleEndian.PutUint16(data[10:], 99)
+		}
+	})
+	r, err := NewReader(bytes.NewReader(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []string{
+		strings.Replace(expected[0], "|64512|12357|", "|0|0|", 1),
+		expected[1],
+		expected[2],
+	}
+	for _, expec := range tests {
+		f, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := f.String(); actual != expec {
+			t.Errorf("read: expected %s, actual %s", expec, actual)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("read: expected %v, actual %v", io.EOF, err)
+	}
+}
@@ -0,0 +1,154 @@
+package sflow
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+// Protocols of the raw packet headers.
+const (
+	headerEthernet = 1
+	headerIPv4     = 11
+	headerIPv6     = 12
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+)
+
+// IP protocols whose headers are decoded.
+const (
+	protoICMP   = 1
+	protoTCP    = 6
+	protoUDP    = 17
+	protoICMPv6 = 58
+	protoSCTP   = 132
+
+	// IPv6 extension headers that are skipped to find the transport header.
+	protoHopByHop    = 0
+	protoRouting     = 43
+	protoFragment    = 44
+	protoDestOptions = 60
+)
+
+// decodeRawPacketHeader sets the fields of f from the sampled packet header,
+// which is usually truncated. Its Bytes is the length of the IP packet found
+// in the IP header. It returns false when the packet is not IPv4 or IPv6.
+func decodeRawPacketHeader(r *reader, f *entry.Flow) bool {
+	protocol := r.uint32()
+	r.uint32() // frame length
+	r.uint32() // stripped
+	h := r.bytes(r.uint32())
+	if r.err != nil {
+		return false
+	}
+	var etherType uint16
+	switch protocol {
+	case headerEthernet:
+		etherType, h = ethernet(h)
+	case headerIPv4:
+		etherType = etherTypeIPv4
+	case headerIPv6:
+		etherType = etherTypeIPv6
+	}
+	switch etherType {
+	case etherTypeIPv4:
+		return decodeIPv4(h, f)
+	case etherTypeIPv6:
+		return decodeIPv6(h, f)
+	}
+	return false
+}
+
+// ethernet returns the type and the payload of an Ethernet frame, skipping
+// its VLAN tags.
+func ethernet(h []byte) (uint16, []byte) {
+	if len(h) < 14 {
+		return 0, nil
+	}
+	t := binary.BigEndian.Uint16(h[12:])
+	h = h[14:]
+	for (t == etherTypeVLAN || t == etherTypeQinQ) && len(h) >= 4 {
+		t = binary.BigEndian.Uint16(h[2:])
+		h = h[4:]
+	}
+	return t, h
+}
+
+func decodeIPv4(h []byte, f *entry.Flow) bool {
+	if len(h) < 20 || h[0]>>4 != 4 {
+		return false
+	}
+	f.SrcTos = h[1]
+	f.Bytes = uint64(binary.BigEndian.Uint16(h[2:]))
+	f.Protocol = h[9]
+	f.SrcAddr = net.IP(append([]byte(nil), h[12:16]...))
+	f.DstAddr = net.IP(append([]byte(nil), h[16:20]...))
+
+	// Only the first fragment has the transport header.
+	ihl := int(h[0]&0x0f) * 4
+	if binary.BigEndian.Uint16(h[6:])&0x1fff == 0 && ihl >= 20 && len(h) >= ihl {
+		decodeTransport(h[ihl:], f)
+	}
+	return true
+}
+
+func decodeIPv6(h []byte, f *entry.Flow) bool {
+	if len(h) < 40 || h[0]>>4 != 6 {
+		return false
+	}
+	f.SrcTos = h[0]<<4 | h[1]>>4
+	f.Bytes = uint64(binary.BigEndian.Uint16(h[4:])) + 40
+	f.SrcAddr = net.IP(append([]byte(nil), h[8:24]...))
+	f.DstAddr = net.IP(append([]byte(nil), h[24:40]...))
+
+	next, l4 := h[6], h[40:]
+	for {
+		f.Protocol = next
+		switch next {
+		case protoHopByHop, protoRouting, protoDestOptions:
+			if len(l4) < 2 || len(l4) < (int(l4[1])+1)*8 {
+				return true
+			}
+			next, l4 = l4[0], l4[(int(l4[1])+1)*8:]
+		case protoFragment:
+			if len(l4) < 8 {
+				return true
+			}
+			next = l4[0]
+			if binary.BigEndian.Uint16(l4[2:])&0xfff8 != 0 {
+				f.Protocol = next
+				return true
+			}
+			l4 = l4[8:]
+		default:
+			decodeTransport(l4, f)
+			return true
+		}
+	}
+}
+
+// decodeTransport sets the ports of f, and the flags of TCP. Like NetFlow,
+// the type and code of ICMP messages are given in the destination port.
+func decodeTransport(l4 []byte, f *entry.Flow) {
+	switch f.Protocol {
+	case protoTCP, protoUDP, protoSCTP:
+		if len(l4) < 4 {
+			return
+		}
+		f.SrcPort = binary.BigEndian.Uint16(l4)
+		f.DstPort = binary.BigEndian.Uint16(l4[2:])
+		if f.Protocol == protoTCP && len(l4) >= 14 {
+			f.TcpFlags = l4[13]
+		}
+	case protoICMP, protoICMPv6:
+		if len(l4) >= 2 {
+			f.DstPort = binary.BigEndian.Uint16(l4)
+		}
+	}
+}
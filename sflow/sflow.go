@@ -0,0 +1,224 @@
+// Package sflow decodes the datagrams sent by sFlow v5 agents into sampled
+// flows.
+package sflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/sevein/nfdmp2rds/entry"
+)
+
+var (
+	// ErrVersion is returned when a datagram is not in version 5 of sFlow.
+	ErrVersion = errors.New("sflow: unsupported version")
+
+	// ErrShortDatagram is returned when a datagram, or one of its samples,
+	// is shorter than its fields say.
+	ErrShortDatagram = errors.New("sflow: short datagram")
+)
+
+// Formats of the samples and flow records that are decoded. The enterprise
+// is 0, i.e. they are defined by sFlow.org. Others, like counter samples,
+// are skipped.
+const (
+	formatFlowSample         = 1
+	formatExpandedFlowSample = 3
+	formatRawPacketHeader    = 1
+	formatExtendedGateway    = 1003
+)
+
+// Types of the addresses.
+const (
+	addrUnknown = 0
+	addrIPv4    = 1
+	addrIPv6    = 2
+)
+
+// Decode decodes an sFlow v5 datagram received at t, and returns a flow for
+// each flow sample whose packet header is IPv4 or IPv6. Each flow stands for
+// a single sampled packet, so its Packets is the sampling rate and its Bytes
+// the length of the packet scaled by the sampling rate. Agents do not send
+// the time when packets were sampled, so First and Last are t. When a sample
+// can not be decoded, the flows of the previous samples are returned along
+// with ErrShortDatagram.
+func Decode(b []byte, t time.Time) ([]*entry.Flow, error) {
+	t = t.Truncate(time.Millisecond)
+	r := reader{b: b}
+	version := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if version != 5 {
+		return nil, ErrVersion
+	}
+	r.addr()   // agent address
+	r.uint32() // sub agent id
+	r.uint32() // sequence number
+	r.uint32() // uptime
+	n := r.uint32()
+
+	var flows []*entry.Flow
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		format := r.uint32()
+		s := reader{b: r.bytes(r.uint32())}
+		if r.err != nil {
+			break
+		}
+		var f *entry.Flow
+		switch format {
+		case formatFlowSample:
+			f = decodeFlowSample(&s, false, t)
+		case formatExpandedFlowSample:
+			f = decodeFlowSample(&s, true, t)
+		}
+		if s.err != nil {
+			r.err = s.err
+			break
+		}
+		if f != nil {
+			flows = append(flows, f)
+		}
+	}
+	return flows, r.err
+}
+
+// decodeFlowSample decodes a flow sample, or an expanded flow sample, which
+// has room for larger source ids and interface indexes. It returns nil when
+// the sample has no IP packet header.
+func decodeFlowSample(r *reader, expanded bool, t time.Time) *entry.Flow {
+	r.uint32() // sequence number
+	r.uint32() // source id
+	if expanded {
+		r.uint32() // source id index
+	}
+	rate := r.uint32()
+	r.uint32() // sample pool
+	r.uint32() // drops
+	var input, output uint32
+	if expanded {
+		input = ifIndex(r.uint32(), r.uint32())
+		output = ifIndex(r.uint32(), r.uint32())
+	} else {
+		v := r.uint32()
+		input = ifIndex(v>>30, v&0x3fffffff)
+		v = r.uint32()
+		output = ifIndex(v>>30, v&0x3fffffff)
+	}
+	if rate == 0 {
+		rate = 1
+	}
+
+	f := &entry.Flow{
+		First:        t,
+		Last:         t,
+		InputSnmp:    input,
+		OutputSnmp:   output,
+		SamplingRate: rate,
+	}
+	ok := false
+	n := r.uint32()
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		format := r.uint32()
+		rec := reader{b: r.bytes(r.uint32())}
+		if r.err != nil {
+			break
+		}
+		switch format {
+		case formatRawPacketHeader:
+			ok = decodeRawPacketHeader(&rec, f)
+		case formatExtendedGateway:
+			decodeExtendedGateway(&rec, f)
+		}
+		if rec.err != nil {
+			r.err = rec.err
+		}
+	}
+	if !ok || r.err != nil {
+		return nil
+	}
+	f.Packets = uint64(rate)
+	f.Bytes *= uint64(rate)
+	return f
+}
+
+// ifIndex returns the index of an interface given in the format of the flow
+// samples, or 0 when the packet was not received or sent by a single one.
+func ifIndex(format, value uint32) uint32 {
+	if format != 0 {
+		return 0
+	}
+	return value
+}
+
+// decodeExtendedGateway sets the AS numbers of f from the BGP routing
+// information of the sample. The destination AS is the last of the AS path.
+func decodeExtendedGateway(r *reader, f *entry.Flow) {
+	r.addr()   // next hop
+	r.uint32() // AS of the router
+	f.SrcAs = r.uint32()
+	r.uint32() // source peer AS
+	segments := r.uint32()
+	for i := uint32(0); i < segments && r.err == nil; i++ {
+		r.uint32() // segment type
+		n := r.uint32()
+		if n > uint32(len(r.b)/4) {
+			r.err = ErrShortDatagram
+			return
+		}
+		for j := uint32(0); j < n; j++ {
+			f.DstAs = r.uint32()
+		}
+	}
+}
+
+// reader reads the big-endian fields of a datagram, which are padded to 4
+// bytes, remembering whether it ran short so it can be checked once.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) uint32() uint32 {
+	if len(r.b) < 4 {
+		r.err = ErrShortDatagram
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+// bytes returns the next n bytes, skipping their padding.
+func (r *reader) bytes(n uint32) []byte {
+	padded := (uint64(n) + 3) &^ 3
+	if r.err != nil || uint64(len(r.b)) < padded {
+		r.err = ErrShortDatagram
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[padded:]
+	return b
+}
+
+// addr returns an address preceded by its type, or nil when its type is
+// unknown.
+func (r *reader) addr() net.IP {
+	var b []byte
+	switch r.uint32() {
+	case addrUnknown:
+		return nil
+	case addrIPv4:
+		b = r.bytes(net.IPv4len)
+	case addrIPv6:
+		b = r.bytes(net.IPv6len)
+	default:
+		r.err = ErrShortDatagram
+	}
+	if r.err != nil {
+		return nil
+	}
+	return net.IP(append([]byte(nil), b...))
+}
@@ -0,0 +1,71 @@
+package sflow
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// sflow.bin holds a counter sample, a flow sample of a tagged Ethernet frame
+// with its BGP routing information, an expanded flow sample of an IPv6
+// packet, a sample of an unknown enterprise and a flow sample of an ARP
+// frame. The counter sample and the last two are skipped.
+var received = time.Unix(1463425860, 123456789)
+
+var expected = []struct {
+	flow string
+	rate uint32
+}{
+	{"2|1463425860|123|1463425860|123|6|0|0|0|2386192149|443|0|0|0|3641448481|57145|64512|12357|39|41|24|0|512|768000", 512},
+	{"10|1463425860|123|1463425860|123|17|536954976|1214251008|0|34952|53|704648272|1073940490|0|8206|1024|0|0|70000|41|0|32|1000|120000", 1000},
+}
+
+func readDatagram(t *testing.T) []byte {
+	b, err := ioutil.ReadFile("testdata/sflow.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	flows, err := Decode(readDatagram(t), received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flows) != len(expected) {
+		t.Fatalf("sflow: expected %d flows, actual %d", len(expected), len(flows))
+	}
+	for i, f := range flows {
+		if actual := f.String(); actual != expected[i].flow {
+			t.Errorf("sflow: expected %s, actual %s", expected[i].flow, actual)
+		}
+		if f.SamplingRate != expected[i].rate {
+			t.Errorf("sflow: expected sampling rate %d, actual %d", expected[i].rate, f.SamplingRate)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	b := readDatagram(t)
+	v4 := append([]byte(nil), b...)
+	v4[3] = 4
+
+	var tests = []struct {
+		name  string
+		input []byte
+		flows int
+		err   error
+	}{
+		{"empty", nil, 0, ErrShortDatagram},
+		{"version", v4, 0, ErrVersion},
+		{"header", b[:20], 0, ErrShortDatagram},
+		{"truncated", b[:len(b)-8], 2, ErrShortDatagram},
+	}
+	for _, tt := range tests {
+		flows, err := Decode(tt.input, received)
+		if err != tt.err || len(flows) != tt.flows {
+			t.Errorf("decode(%s): expected %v and %d flows, actual %v and %d flows", tt.name, tt.err, tt.flows, err, len(flows))
+		}
+	}
+}
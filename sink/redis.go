@@ -0,0 +1,313 @@
+package sink
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+var (
+	// MaxRetries is the number of times that the delivery of an entry is
+	// retried after a transient failure.
+	MaxRetries = flag.Int("maxRetries", 5, "Retry transient output failures up to this many times")
+
+	// RetryBackoff is the delay before the first retry, doubled after each
+	// attempt up to MaxRetryBackoff.
+	RetryBackoff    = flag.Duration("retryBackoff", 100*time.Millisecond, "Delay before retrying, doubled after each attempt")
+	MaxRetryBackoff = flag.Duration("maxRetryBackoff", 10*time.Second, "Maximum delay between retries")
+)
+
+const defaultRedisServer = ":6379"
+
+// transientRedisErrors are the prefixes of the error replies that a server
+// may send while it is loading its dataset or after a failover.
+var transientRedisErrors = []string{"LOADING", "READONLY", "MASTERDOWN", "TRYAGAIN"}
+
+// redisClient holds what is common to all the Redis sinks.
+type redisClient struct {
+	pool *redis.Pool
+}
+
+func (c *redisClient) Open() error {
+	conn := c.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PING")
+	return err
+}
+
+// Flush does nothing because Write waits for the replies of the server.
+func (c *redisClient) Flush() error {
+	return nil
+}
+
+func (c *redisClient) Close() error {
+	return c.pool.Close()
+}
+
+// write calls fn with a connection and the entries of the batch that are
+// still pending. Entries that fail with a transient error are retried, with
+// capped exponential backoff, on a new connection borrowed from the pool.
+// Entries may be delivered more than once if a connection breaks before the
+// server replies.
+func (c *redisClient) write(batch [][]byte, fn func(redis.Conn, [][]byte) error) error {
+	errs := make(BatchError, len(batch))
+	pending := make([]int, len(batch))
+	for i := range pending {
+		pending[i] = i
+	}
+	backoff := *RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		entries := make([][]byte, len(pending))
+		for j, i := range pending {
+			entries[j] = batch[i]
+		}
+		conn := c.pool.Get()
+		err := fn(conn, entries)
+		conn.Close()
+
+		var retry []int
+		for j, err := range Errors(err, len(entries)) {
+			i := pending[j]
+			errs[i] = err
+			if err != nil && temporary(err) {
+				retry = append(retry, i)
+			}
+		}
+		if len(retry) == 0 || attempt >= *MaxRetries {
+			break
+		}
+		pending = retry
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > *MaxRetryBackoff {
+			backoff = *MaxRetryBackoff
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return errs
+		}
+	}
+	return nil
+}
+
+// temporary reports whether err may go away by retrying. Error replies are
+// final unless the server is loading or read-only, but any other error means
+// that the connection broke.
+func temporary(err error) bool {
+	rerr, ok := err.(redis.Error)
+	if !ok {
+		return true
+	}
+	for _, prefix := range transientRedisErrors {
+		if strings.HasPrefix(string(rerr), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redisSink holds what is common to the sinks that write to a Redis key.
+type redisSink struct {
+	redisClient
+	key string
+}
+
+func newRedisSink(u *url.URL) (redisSink, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" {
+		return redisSink{}, errors.New("redis output requires a key")
+	}
+	return redisSink{redisClient{newRedisPool(u)}, key}, nil
+}
+
+// Reset deletes the key.
+func (s *redisSink) Reset() error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("DEL", s.key)
+	return err
+}
+
+// redisList pushes entries to a Redis list with LPUSH.
+type redisList struct {
+	redisSink
+}
+
+func newRedisList(u *url.URL) (*redisList, error) {
+	rs, err := newRedisSink(u)
+	if err != nil {
+		return nil, err
+	}
+	return &redisList{rs}, nil
+}
+
+func (s *redisList) String() string {
+	return fmt.Sprintf("Redis list %q", s.key)
+}
+
+// Write pushes the whole batch with a single LPUSH.
+func (s *redisList) Write(batch [][]byte) error {
+	return s.write(batch, func(conn redis.Conn, batch [][]byte) error {
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, s.key)
+		for _, e := range batch {
+			args = append(args, e)
+		}
+		_, err := conn.Do("LPUSH", args...)
+		return err
+	})
+}
+
+// Count returns the length of the list.
+func (s *redisList) Count() (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	return redis.Int64(conn.Do("LLEN", s.key))
+}
+
+// redisStream appends entries to a Redis stream with XADD, so they can be
+// shared by consumer groups. Each stream entry holds a single field named
+// after streamField. The stream is capped to approximately maxlen entries
+// when maxlen is greater than zero.
+type redisStream struct {
+	redisSink
+	maxlen int64
+}
+
+const streamField = "entry"
+
+func newRedisStream(u *url.URL) (*redisStream, error) {
+	rs, err := newRedisSink(u)
+	if err != nil {
+		return nil, err
+	}
+	s := &redisStream{redisSink: rs}
+	if v := u.Query().Get("maxlen"); v != "" {
+		s.maxlen, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || s.maxlen < 0 {
+			return nil, fmt.Errorf("invalid maxlen %q", v)
+		}
+	}
+	return s, nil
+}
+
+func (s *redisStream) String() string {
+	return fmt.Sprintf("Redis stream %q", s.key)
+}
+
+func (s *redisStream) Write(batch [][]byte) error {
+	return s.write(batch, func(conn redis.Conn, batch [][]byte) error {
+		return pipeline(conn, batch, "XADD", s.args)
+	})
+}
+
+func (s *redisStream) args(e []byte) []interface{} {
+	if s.maxlen > 0 {
+		return []interface{}{s.key, "MAXLEN", "~", s.maxlen, "*", streamField, e}
+	}
+	return []interface{}{s.key, "*", streamField, e}
+}
+
+// Count returns the length of the stream.
+func (s *redisStream) Count() (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	return redis.Int64(conn.Do("XLEN", s.key))
+}
+
+// redisPubSub publishes entries to a Redis channel so subscribers can follow
+// them live. Entries are not stored.
+type redisPubSub struct {
+	redisClient
+	channel string
+}
+
+func newRedisPubSub(u *url.URL) (*redisPubSub, error) {
+	channel := strings.TrimPrefix(u.Path, "/")
+	if channel == "" {
+		return nil, errors.New("redis pub/sub output requires a channel")
+	}
+	return &redisPubSub{redisClient{newRedisPool(u)}, channel}, nil
+}
+
+func (s *redisPubSub) String() string {
+	return fmt.Sprintf("Redis channel %q", s.channel)
+}
+
+func (s *redisPubSub) Write(batch [][]byte) error {
+	return s.write(batch, func(conn redis.Conn, batch [][]byte) error {
+		return pipeline(conn, batch, "PUBLISH", func(e []byte) []interface{} {
+			return []interface{}{s.channel, e}
+		})
+	})
+}
+
+// pipeline sends one command per entry of the batch in a single round-trip
+// and reads all the replies back. If any command fails, it returns a
+// BatchError.
+func pipeline(conn redis.Conn, batch [][]byte, cmd string, args func([]byte) []interface{}) error {
+	for _, e := range batch {
+		if err := conn.Send(cmd, args(e)...); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	var errs BatchError
+	for i := range batch {
+		if _, err := conn.Receive(); err != nil {
+			if errs == nil {
+				errs = make(BatchError, len(batch))
+			}
+			errs[i] = err
+		}
+	}
+	if errs == nil {
+		return nil
+	}
+	return errs
+}
+
+// newRedisPool returns a pool of connections to the server in u, which may
+// carry a password in its user information.
+func newRedisPool(u *url.URL) *redis.Pool {
+	server := u.Host
+	if server == "" {
+		server = defaultRedisServer
+	}
+	var password string
+	if u.User != nil {
+		password, _ = u.User.Password()
+	}
+	return &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			c, err := redis.Dial("tcp", server)
+			if err != nil {
+				return nil, err
+			}
+			if password != "" {
+				if _, err := c.Do("AUTH", password); err != nil {
+					c.Close()
+					return nil, err
+				}
+			}
+			return c, err
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+}
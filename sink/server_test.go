@@ -0,0 +1,251 @@
+package sink
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis is an in-process Redis server that understands the handful of
+// commands used by the sinks.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu      sync.Mutex
+	lists   map[string][]string
+	streams map[string][]string
+	channel map[string][]string
+	seq     int
+	errs    map[string]*fakeError
+	rejects map[string]string
+}
+
+// fakeError is the error reply sent to a command the next n times, or always
+// if n is negative. An empty reply makes the server drop the connection.
+type fakeError struct {
+	reply string
+	n     int
+}
+
+// newFakeRedis starts a fake server. Clients must authenticate when password
+// is not empty.
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{
+		ln:       ln,
+		password: password,
+		lists:    make(map[string][]string),
+		streams:  make(map[string][]string),
+		channel:  make(map[string][]string),
+		errs:     make(map[string]*fakeError),
+		rejects:  make(map[string]string),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeRedis) close() {
+	s.ln.Close()
+}
+
+// fail makes the server reply to cmd with the given error.
+func (s *fakeRedis) fail(cmd, reply string) {
+	s.failN(cmd, reply, -1)
+}
+
+// failN makes the server reply to cmd with the given error the next n times.
+func (s *fakeRedis) failN(cmd, reply string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs[cmd] = &fakeError{reply, n}
+}
+
+// reject makes the server reply with the given error to any command that has
+// value as an argument.
+func (s *fakeRedis) reject(value, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejects[value] = reply
+}
+
+func (s *fakeRedis) list(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lists[key]...)
+}
+
+// stream returns the values of the entries of a stream, oldest first.
+func (s *fakeRedis) stream(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.streams[key]...)
+}
+
+// published returns the messages published to a channel.
+func (s *fakeRedis) published(channel string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.channel[channel]...)
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if cmd == "AUTH" {
+			authed = len(args) == 2 && args[1] == s.password
+			if authed {
+				w.WriteString("+OK\r\n")
+			} else {
+				w.WriteString("-ERR invalid password\r\n")
+			}
+		} else if !authed {
+			w.WriteString("-NOAUTH Authentication required.\r\n")
+		} else {
+			reply := s.exec(cmd, args[1:])
+			if reply == "" {
+				return
+			}
+			w.WriteString(reply)
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec runs a command and returns its reply, or an empty string if the
+// connection must be dropped.
+func (s *fakeRedis) exec(cmd string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.errs[cmd]; ok && e.n != 0 {
+		e.n--
+		if e.reply == "" {
+			return ""
+		}
+		return "-" + e.reply + "\r\n"
+	}
+	for _, arg := range args {
+		if reply, ok := s.rejects[arg]; ok {
+			return "-" + reply + "\r\n"
+		}
+	}
+	switch cmd {
+	case "PING":
+		return "+PONG\r\n"
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := s.lists[key]; ok {
+				delete(s.lists, key)
+				n++
+			}
+			if _, ok := s.streams[key]; ok {
+				delete(s.streams, key)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "LPUSH":
+		key := args[0]
+		for _, v := range args[1:] {
+			s.lists[key] = append([]string{v}, s.lists[key]...)
+		}
+		return fmt.Sprintf(":%d\r\n", len(s.lists[key]))
+	case "LLEN":
+		return fmt.Sprintf(":%d\r\n", len(s.lists[args[0]]))
+	case "XADD":
+		key, args := args[0], args[1:]
+		maxlen := -1
+		if strings.ToUpper(args[0]) == "MAXLEN" {
+			args = args[1:]
+			if args[0] == "~" || args[0] == "=" {
+				args = args[1:]
+			}
+			maxlen, _ = strconv.Atoi(args[0])
+			args = args[1:]
+		}
+		if len(args) < 3 || args[0] != "*" {
+			return "-ERR syntax error\r\n"
+		}
+		entries := append(s.streams[key], args[2])
+		if maxlen >= 0 && len(entries) > maxlen {
+			entries = entries[len(entries)-maxlen:]
+		}
+		s.streams[key] = entries
+		s.seq++
+		id := fmt.Sprintf("1-%d", s.seq)
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(id), id)
+	case "PUBLISH":
+		s.channel[args[0]] = append(s.channel[args[0]], args[1])
+		return ":0\r\n"
+	case "XLEN":
+		return fmt.Sprintf(":%d\r\n", len(s.streams[args[0]]))
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", cmd)
+}
+
+// readCommand reads a command sent by a client as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readHeader(r, '*')
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		size, err := readHeader(r, '$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readHeader(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+	return strconv.Atoi(strings.TrimRight(line[1:], "\r\n"))
+}
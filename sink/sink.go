@@ -0,0 +1,166 @@
+// Package sink implements the backends where nfdmp2rds delivers entries.
+package sink
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Sink is an output backend for marshalled entries. Implementations are safe
+// for concurrent use by multiple goroutines.
+type Sink interface {
+	fmt.Stringer
+
+	// Open prepares the sink to accept entries.
+	Open() error
+
+	// Write delivers a batch of entries, in as few round-trips to the backend
+	// as possible, and reports whether the backend accepted them. When only
+	// some entries are rejected the error returned is a BatchError.
+	Write(batch [][]byte) error
+
+	// Flush delivers the entries that the sink may be holding back.
+	Flush() error
+
+	// Close releases the resources used by the sink.
+	Close() error
+}
+
+// BatchError is returned by Write when some entries of a batch were rejected.
+// It holds the error of every entry of the batch, nil for those written.
+type BatchError []error
+
+func (e BatchError) Error() string {
+	var first error
+	failed := 0
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d entries failed, first error: %s", failed, len(e), first)
+}
+
+// Errors returns the error of each entry of a batch of n entries given the
+// error returned by Write. Every entry failed unless err is a BatchError.
+func Errors(err error, n int) []error {
+	if be, ok := err.(BatchError); ok {
+		return be
+	}
+	errs := make([]error, n)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+// Resetter is implemented by sinks that can discard the entries that they
+// hold.
+type Resetter interface {
+	Reset() error
+}
+
+// Counter is implemented by sinks that can report how many entries they hold.
+type Counter interface {
+	Count() (int64, error)
+}
+
+// New returns the Sink described by rawurl. The following are supported:
+//
+//	redis://:password@localhost:6379/netflow:test001
+//	redis+stream://localhost:6379/netflow:test001?maxlen=1000000
+//	redis+pubsub://localhost:6379/netflow:live
+//
+// They push entries to a Redis list, append them to a Redis stream optionally
+// capped to approximately maxlen entries, or publish them to a Redis channel.
+func New(rawurl string) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "redis":
+		return newRedisList(u)
+	case "redis+stream":
+		return newRedisStream(u)
+	case "redis+pubsub":
+		return newRedisPubSub(u)
+	}
+	return nil, fmt.Errorf("unsupported output %q", rawurl)
+}
+
+// multi fans entries out to a number of sinks.
+type multi []Sink
+
+// Multi returns a Sink that writes every entry to all the sinks given.
+func Multi(sinks ...Sink) Sink {
+	if len(sinks) == 1 {
+		return sinks[0]
+	}
+	return multi(sinks)
+}
+
+func (m multi) String() string {
+	names := make([]string, len(m))
+	for i, s := range m {
+		names[i] = s.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (m multi) Open() error {
+	for _, s := range m {
+		if err := s.Open(); err != nil {
+			return fmt.Errorf("%s: %s", s, err)
+		}
+	}
+	return nil
+}
+
+// Write writes the batch to every sink, even if some of them fail. An entry
+// has failed if any of the sinks rejected it.
+func (m multi) Write(batch [][]byte) error {
+	var errs BatchError
+	for _, s := range m {
+		err := s.Write(batch)
+		if err == nil {
+			continue
+		}
+		if errs == nil {
+			errs = make(BatchError, len(batch))
+		}
+		for i, err := range Errors(err, len(batch)) {
+			if err != nil && errs[i] == nil {
+				errs[i] = fmt.Errorf("%s: %s", s, err)
+			}
+		}
+	}
+	if errs == nil {
+		return nil
+	}
+	return errs
+}
+
+func (m multi) Flush() error {
+	return m.each(Sink.Flush)
+}
+
+func (m multi) Close() error {
+	return m.each(Sink.Close)
+}
+
+func (m multi) each(fn func(Sink) error) error {
+	var first error
+	for _, s := range m {
+		if err := fn(s); err != nil && first == nil {
+			first = fmt.Errorf("%s: %s", s, err)
+		}
+	}
+	return first
+}
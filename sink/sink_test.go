@@ -0,0 +1,229 @@
+package sink
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func init() {
+	*RetryBackoff = time.Millisecond
+}
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		input string
+		valid bool
+	}{
+		{"redis://localhost:6379/netflow:test001", true},
+		{"redis://:secret@localhost/netflow:test001", true},
+		{"redis://localhost:6379/", false},
+		{"redis+stream://localhost:6379/netflow:test001", true},
+		{"redis+stream://localhost:6379/netflow:test001?maxlen=1000", true},
+		{"redis+stream://localhost:6379/netflow:test001?maxlen=lots", false},
+		{"redis+pubsub://localhost:6379/netflow:live", true},
+		{"redis+pubsub://localhost:6379", false},
+		{"kafka://localhost:9092/netflow", false},
+	}
+	for _, tt := range tests {
+		s, err := New(tt.input)
+		if tt.valid && err != nil {
+			t.Errorf("new(%s): unexpected error %s", tt.input, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("new(%s): expected error, got %s", tt.input, s)
+		}
+	}
+}
+
+func TestRedisList(t *testing.T) {
+	srv := newFakeRedis(t, "secret")
+	defer srv.close()
+
+	s, err := New("redis://:secret@" + srv.addr() + "/netflow:test001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Write([][]byte{[]byte("a"), []byte("b"), []byte("c")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expec := []string{"c", "b", "a"}
+	if actual := srv.list("netflow:test001"); !reflect.DeepEqual(actual, expec) {
+		t.Errorf("list: expected %v, actual %v", expec, actual)
+	}
+	if count, err := s.(Counter).Count(); err != nil || count != 3 {
+		t.Errorf("count: expected 3, actual %d (%v)", count, err)
+	}
+
+	if err := s.(Resetter).Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := s.(Counter).Count(); err != nil || count != 0 {
+		t.Errorf("count: expected 0, actual %d (%v)", count, err)
+	}
+}
+
+func TestRedisStream(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	defer srv.close()
+
+	s, err := New("redis+stream://" + srv.addr() + "/netflow:test001?maxlen=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Write([][]byte{[]byte("a"), []byte("b"), []byte("c")}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := s.(Counter).Count(); err != nil || count != 2 {
+		t.Errorf("count: expected 2, actual %d (%v)", count, err)
+	}
+	expec := []string{"b", "c"}
+	if actual := srv.stream("netflow:test001"); !reflect.DeepEqual(actual, expec) {
+		t.Errorf("stream: expected %v, actual %v", expec, actual)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	defer srv.close()
+
+	var sinks []Sink
+	for _, u := range []string{"redis://" + srv.addr() + "/netflow:test001", "redis+pubsub://" + srv.addr() + "/netflow:live"} {
+		s, err := New(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		sinks = append(sinks, s)
+	}
+	s := Multi(sinks...)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Write([][]byte{[]byte("a"), []byte("b")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := sinks[0].(Counter).Count(); err != nil || count != 2 {
+		t.Errorf("count: expected 2, actual %d (%v)", count, err)
+	}
+	expec := []string{"a", "b"}
+	if actual := srv.published("netflow:live"); !reflect.DeepEqual(actual, expec) {
+		t.Errorf("published: expected %v, actual %v", expec, actual)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	defer srv.close()
+	srv.fail("LPUSH", "WRONGTYPE Operation against a key holding the wrong kind of value")
+	srv.fail("XADD", "OOM command not allowed when used memory > 'maxmemory'.")
+
+	for _, u := range []string{"redis://" + srv.addr() + "/netflow:test001", "redis+stream://" + srv.addr() + "/netflow:test001"} {
+		s, err := New(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		if err := s.Write([][]byte{[]byte("a"), []byte("b")}); err == nil {
+			t.Errorf("write(%s): expected error", s)
+		}
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	defer srv.close()
+	srv.reject("b", "ERR rejected")
+
+	var tests = []struct {
+		url   string
+		expec []bool
+	}{
+		{"redis://" + srv.addr() + "/netflow:test001", []bool{true, true, true}},
+		{"redis+stream://" + srv.addr() + "/netflow:test001", []bool{false, true, false}},
+		{"redis+pubsub://" + srv.addr() + "/netflow:live", []bool{false, true, false}},
+	}
+	for _, tt := range tests {
+		s, err := New(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		err = s.Write([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+		for i, err := range Errors(err, 3) {
+			if (err != nil) != tt.expec[i] {
+				t.Errorf("write(%s): entry %d expected failure %v, actual %v", s, i, tt.expec[i], err)
+			}
+		}
+	}
+	expec := []string{"a", "c"}
+	if actual := srv.stream("netflow:test001"); !reflect.DeepEqual(actual, expec) {
+		t.Errorf("stream: expected %v, actual %v", expec, actual)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	defer srv.close()
+
+	var tests = []struct {
+		url   string
+		cmd   string
+		reply string
+		times int
+		fails bool
+	}{
+		{"redis://" + srv.addr() + "/netflow:test001", "LPUSH", "", 2, false},
+		{"redis://" + srv.addr() + "/netflow:test001", "LPUSH", "LOADING Redis is loading the dataset in memory", 3, false},
+		{"redis+stream://" + srv.addr() + "/netflow:test001", "XADD", "READONLY You can't write against a read only replica.", 1, false},
+		{"redis+stream://" + srv.addr() + "/netflow:test001", "XADD", "LOADING Redis is loading the dataset in memory", *MaxRetries + 1, true},
+		{"redis+pubsub://" + srv.addr() + "/netflow:live", "PUBLISH", "ERR unknown command", 1, true},
+	}
+	for _, tt := range tests {
+		srv.failN(tt.cmd, tt.reply, tt.times)
+		s, err := New(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		err = s.Write([][]byte{[]byte("a")})
+		if tt.fails && err == nil {
+			t.Errorf("write(%s, %q x%d): expected error", s, tt.reply, tt.times)
+		}
+		if !tt.fails && err != nil {
+			t.Errorf("write(%s, %q x%d): unexpected error %s", s, tt.reply, tt.times, err)
+		}
+		srv.failN(tt.cmd, "", 0)
+	}
+}
+
+func TestRedisAuth(t *testing.T) {
+	srv := newFakeRedis(t, "secret")
+	defer srv.close()
+
+	s, err := New("redis://:wrong@" + srv.addr() + "/netflow:test001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.Open(); err == nil {
+		t.Error("open: expected authentication error")
+	}
+}